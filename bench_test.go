@@ -0,0 +1,79 @@
+package bqschema
+
+import (
+	"fmt"
+	"reflect"
+	"testing"
+	"time"
+)
+
+// wideType returns a struct type with n fields of varied types and tags.
+func wideType(n int) reflect.Type {
+	types := []reflect.Type{
+		reflect.TypeOf(0),
+		reflect.TypeOf(""),
+		reflect.TypeOf(0.0),
+		reflect.TypeOf(time.Time{}),
+		reflect.TypeOf([]string{}),
+		reflect.TypeOf((*int64)(nil)),
+	}
+	fields := make([]reflect.StructField, n)
+	for i := range fields {
+		fields[i] = reflect.StructField{
+			Name: fmt.Sprintf("Field%d", i),
+			Type: types[i%len(types)],
+			Tag:  reflect.StructTag(fmt.Sprintf(`json:"field_%d,omitempty"`, i)),
+		}
+	}
+	return reflect.StructOf(fields)
+}
+
+// deepType returns a struct type nesting depth records of a few fields each.
+func deepType(depth int) reflect.Type {
+	t := reflect.StructOf([]reflect.StructField{{Name: "Leaf", Type: reflect.TypeOf("")}})
+	for i := 0; i < depth; i++ {
+		t = reflect.StructOf([]reflect.StructField{
+			{Name: "ID", Type: reflect.TypeOf(0)},
+			{Name: "Name", Type: reflect.TypeOf("")},
+			{Name: "Child", Type: t},
+			{Name: "Children", Type: reflect.SliceOf(t)},
+		})
+	}
+	return t
+}
+
+func benchmarkToSchema(b *testing.B, t reflect.Type) {
+	src := reflect.New(t).Elem().Interface()
+	o := newOptions(nil)
+	b.ReportAllocs()
+	for i := 0; i < b.N; i++ {
+		if _, err := toSchema(src, o); err != nil {
+			b.Fatal(err)
+		}
+	}
+}
+
+func benchmarkStructToRow(b *testing.B, t reflect.Type) {
+	v := reflect.New(t).Elem()
+	b.ReportAllocs()
+	for i := 0; i < b.N; i++ {
+		structToRow(v)
+	}
+}
+
+func BenchmarkToSchemaWide(b *testing.B)    { benchmarkToSchema(b, wideType(300)) }
+func BenchmarkToSchemaDeep(b *testing.B)    { benchmarkToSchema(b, deepType(6)) }
+func BenchmarkStructToRowWide(b *testing.B) { benchmarkStructToRow(b, wideType(300)) }
+func BenchmarkStructToRowDeep(b *testing.B) { benchmarkStructToRow(b, deepType(6)) }
+func BenchmarkConverterCached(b *testing.B) { benchmarkConverter(b, wideType(300)) }
+
+func benchmarkConverter(b *testing.B, t reflect.Type) {
+	src := reflect.New(t).Elem().Interface()
+	c := NewConverter()
+	b.ReportAllocs()
+	for i := 0; i < b.N; i++ {
+		if _, err := c.ToSchema(src); err != nil {
+			b.Fatal(err)
+		}
+	}
+}
@@ -0,0 +1,125 @@
+// Package cloudschema converts between the table schemas of the generated
+// BigQuery API package, google.golang.org/api/bigquery/v2, which bqschema
+// uses, and those of the cloud.google.com/go/bigquery client, so that code
+// moving from one client library to the other can share schema logic.
+//
+// Conversions preserve descriptions, policy tags, default values, collations,
+// rounding modes, RANGE element types and the length, precision and scale of
+// parameterized types such as STRING(10) and NUMERIC(10, 2).
+//
+// cloudschema is a module of its own, so that depending on bqschema does not
+// pull in the cloud client and the Go version it requires.
+package cloudschema
+
+import (
+	"strings"
+
+	cloudbigquery "cloud.google.com/go/bigquery"
+	"google.golang.org/api/bigquery/v2"
+)
+
+// ToCloudSchema converts schema to a cloud client schema. Standard SQL type
+// names, such as INT64 and STRUCT, convert to their legacy equivalents, which
+// the cloud client uses; columns without a mode are nullable.
+func ToCloudSchema(schema *bigquery.TableSchema) cloudbigquery.Schema {
+	if schema == nil {
+		return nil
+	}
+	return toCloudFields(schema.Fields)
+}
+
+func toCloudFields(fields []*bigquery.TableFieldSchema) cloudbigquery.Schema {
+	if fields == nil {
+		return nil
+	}
+	s := make(cloudbigquery.Schema, len(fields))
+	for i, field := range fields {
+		fs := &cloudbigquery.FieldSchema{
+			Name:                   field.Name,
+			Description:            field.Description,
+			Repeated:               strings.EqualFold(field.Mode, "repeated"),
+			Required:               strings.EqualFold(field.Mode, "required"),
+			Type:                   cloudbigquery.FieldType(fieldType(field.Type)),
+			Schema:                 toCloudFields(field.Fields),
+			MaxLength:              field.MaxLength,
+			Precision:              field.Precision,
+			Scale:                  field.Scale,
+			DefaultValueExpression: field.DefaultValueExpression,
+			Collation:              field.Collation,
+			RoundingMode:           cloudbigquery.RoundingMode(strings.ToUpper(field.RoundingMode)),
+		}
+		if field.PolicyTags != nil {
+			fs.PolicyTags = &cloudbigquery.PolicyTagList{Names: field.PolicyTags.Names}
+		}
+		if field.RangeElementType != nil {
+			fs.RangeElementType = &cloudbigquery.RangeElementType{Type: cloudbigquery.FieldType(fieldType(field.RangeElementType.Type))}
+		}
+		if field.TimestampPrecision != nil {
+			fs.TimestampPrecision = *field.TimestampPrecision
+		}
+		s[i] = fs
+	}
+	return s
+}
+
+// FromCloudSchema converts a cloud client schema to a table schema, with
+// upper case types and modes as returned by the BigQuery API.
+func FromCloudSchema(schema cloudbigquery.Schema) *bigquery.TableSchema {
+	return &bigquery.TableSchema{Fields: fromCloudFields(schema)}
+}
+
+func fromCloudFields(schema cloudbigquery.Schema) []*bigquery.TableFieldSchema {
+	if schema == nil {
+		return nil
+	}
+	fields := make([]*bigquery.TableFieldSchema, len(schema))
+	for i, fs := range schema {
+		field := &bigquery.TableFieldSchema{
+			Name:                   fs.Name,
+			Description:            fs.Description,
+			Mode:                   "NULLABLE",
+			Type:                   string(fs.Type),
+			Fields:                 fromCloudFields(fs.Schema),
+			MaxLength:              fs.MaxLength,
+			Precision:              fs.Precision,
+			Scale:                  fs.Scale,
+			DefaultValueExpression: fs.DefaultValueExpression,
+			Collation:              fs.Collation,
+			RoundingMode:           string(fs.RoundingMode),
+		}
+		switch {
+		case fs.Repeated:
+			field.Mode = "REPEATED"
+		case fs.Required:
+			field.Mode = "REQUIRED"
+		}
+		if fs.PolicyTags != nil {
+			field.PolicyTags = &bigquery.TableFieldSchemaPolicyTags{Names: fs.PolicyTags.Names}
+		}
+		if fs.RangeElementType != nil {
+			field.RangeElementType = &bigquery.TableFieldSchemaRangeElementType{Type: string(fs.RangeElementType.Type)}
+		}
+		if fs.TimestampPrecision != 0 {
+			precision := fs.TimestampPrecision
+			field.TimestampPrecision = &precision
+		}
+		fields[i] = field
+	}
+	return fields
+}
+
+// fieldType returns the upper case legacy name of a column type.
+func fieldType(t string) string {
+	switch t = strings.ToUpper(t); t {
+	case "INT64":
+		return "INTEGER"
+	case "FLOAT64":
+		return "FLOAT"
+	case "BOOL":
+		return "BOOLEAN"
+	case "STRUCT":
+		return "RECORD"
+	default:
+		return t
+	}
+}
@@ -0,0 +1,13 @@
+package cloudschema
+
+import (
+	"testing"
+
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+)
+
+func TestCloudschema(t *testing.T) {
+	RegisterFailHandler(Fail)
+	RunSpecs(t, "Cloudschema Suite")
+}
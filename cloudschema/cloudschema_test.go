@@ -0,0 +1,69 @@
+package cloudschema
+
+import (
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+
+	cloudbigquery "cloud.google.com/go/bigquery"
+	"github.com/nbio/bqschema"
+	"google.golang.org/api/bigquery/v2"
+)
+
+var _ = Describe("Cloud schemas", func() {
+	precision := int64(12)
+	legacy := &bigquery.TableSchema{Fields: []*bigquery.TableFieldSchema{
+		&bigquery.TableFieldSchema{Mode: "REQUIRED", Name: "id", Type: "INTEGER", Description: "Identifier."},
+		&bigquery.TableFieldSchema{Mode: "NULLABLE", Name: "email", Type: "STRING", MaxLength: 320, Collation: "und:ci",
+			PolicyTags: &bigquery.TableFieldSchemaPolicyTags{Names: []string{"projects/p/locations/us/taxonomies/1/policyTags/2"}}},
+		&bigquery.TableFieldSchema{Mode: "NULLABLE", Name: "price", Type: "NUMERIC", Precision: 10, Scale: 2, RoundingMode: "ROUND_HALF_EVEN"},
+		&bigquery.TableFieldSchema{Mode: "NULLABLE", Name: "stay", Type: "RANGE", RangeElementType: &bigquery.TableFieldSchemaRangeElementType{Type: "DATE"}},
+		&bigquery.TableFieldSchema{Mode: "NULLABLE", Name: "created", Type: "TIMESTAMP", DefaultValueExpression: "CURRENT_TIMESTAMP()", TimestampPrecision: &precision},
+		&bigquery.TableFieldSchema{Mode: "REPEATED", Name: "items", Type: "RECORD", Fields: []*bigquery.TableFieldSchema{
+			&bigquery.TableFieldSchema{Mode: "NULLABLE", Name: "sku", Type: "STRING"},
+		}},
+	}}
+	cloud := cloudbigquery.Schema{
+		&cloudbigquery.FieldSchema{Name: "id", Type: cloudbigquery.IntegerFieldType, Required: true, Description: "Identifier."},
+		&cloudbigquery.FieldSchema{Name: "email", Type: cloudbigquery.StringFieldType, MaxLength: 320, Collation: "und:ci",
+			PolicyTags: &cloudbigquery.PolicyTagList{Names: []string{"projects/p/locations/us/taxonomies/1/policyTags/2"}}},
+		&cloudbigquery.FieldSchema{Name: "price", Type: cloudbigquery.NumericFieldType, Precision: 10, Scale: 2, RoundingMode: cloudbigquery.RoundHalfEven},
+		&cloudbigquery.FieldSchema{Name: "stay", Type: cloudbigquery.RangeFieldType, RangeElementType: &cloudbigquery.RangeElementType{Type: cloudbigquery.DateFieldType}},
+		&cloudbigquery.FieldSchema{Name: "created", Type: cloudbigquery.TimestampFieldType, DefaultValueExpression: "CURRENT_TIMESTAMP()", TimestampPrecision: 12},
+		&cloudbigquery.FieldSchema{Name: "items", Type: cloudbigquery.RecordFieldType, Repeated: true, Schema: cloudbigquery.Schema{
+			&cloudbigquery.FieldSchema{Name: "sku", Type: cloudbigquery.StringFieldType},
+		}},
+	}
+
+	It("should convert table schemas to cloud schemas", func() {
+		Expect(ToCloudSchema(legacy)).To(Equal(cloud))
+	})
+
+	It("should convert cloud schemas to table schemas", func() {
+		Expect(FromCloudSchema(cloud)).To(Equal(legacy))
+	})
+
+	It("should convert the schemas of Go types", func() {
+		type row struct {
+			ID   int64    `json:"id"`
+			Tags []string `json:"tags"`
+			Note string   `json:"note,omitempty" description:"Free text."`
+		}
+		Expect(ToCloudSchema(bqschema.MustToSchema(row{}))).To(Equal(cloudbigquery.Schema{
+			&cloudbigquery.FieldSchema{Name: "id", Type: cloudbigquery.IntegerFieldType, Required: true},
+			&cloudbigquery.FieldSchema{Name: "tags", Type: cloudbigquery.StringFieldType, Repeated: true},
+			&cloudbigquery.FieldSchema{Name: "note", Type: cloudbigquery.StringFieldType, Description: "Free text."},
+		}))
+	})
+
+	It("should normalize standard SQL type names", func() {
+		s := ToCloudSchema(&bigquery.TableSchema{Fields: []*bigquery.TableFieldSchema{
+			&bigquery.TableFieldSchema{Name: "a", Type: "int64"},
+			&bigquery.TableFieldSchema{Name: "b", Type: "struct", Fields: []*bigquery.TableFieldSchema{
+				&bigquery.TableFieldSchema{Name: "c", Type: "bool"},
+			}},
+		}})
+		Expect(s[0].Type).To(Equal(cloudbigquery.IntegerFieldType))
+		Expect(s[1].Type).To(Equal(cloudbigquery.RecordFieldType))
+		Expect(s[1].Schema[0].Type).To(Equal(cloudbigquery.BooleanFieldType))
+	})
+})
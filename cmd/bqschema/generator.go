@@ -0,0 +1,591 @@
+package main
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"go/ast"
+	"go/build"
+	"go/format"
+	"go/parser"
+	"go/token"
+	"go/types"
+	"path/filepath"
+	"reflect"
+	"strconv"
+	"strings"
+
+	"github.com/nbio/bqschema"
+	"google.golang.org/api/bigquery/v2"
+)
+
+var (
+	errArrayOfArray = errors.New("Array of Arrays not allowed")
+	errNotStruct    = errors.New("Can not convert non structs")
+)
+
+// generator resolves struct types declared in a single package directory.
+type generator struct {
+	pkgName     string
+	types       map[string]*ast.TypeSpec
+	methods     map[string]map[string]*ast.FuncType // by receiver type name
+	defaultMode string
+}
+
+func newGenerator(dir string) (*generator, error) {
+	pkg, err := build.Default.ImportDir(dir, 0)
+	if err != nil {
+		return nil, err
+	}
+
+	g := &generator{
+		pkgName:     pkg.Name,
+		types:       map[string]*ast.TypeSpec{},
+		methods:     map[string]map[string]*ast.FuncType{},
+		defaultMode: "required",
+	}
+	fset := token.NewFileSet()
+	for _, name := range pkg.GoFiles {
+		f, err := parser.ParseFile(fset, filepath.Join(dir, name), nil, parser.ParseComments)
+		if err != nil {
+			return nil, err
+		}
+		ast.Inspect(f, func(n ast.Node) bool {
+			switch n := n.(type) {
+			case *ast.TypeSpec:
+				g.types[n.Name.Name] = n
+			case *ast.FuncDecl:
+				if n.Recv == nil || len(n.Recv.List) != 1 {
+					break
+				}
+				recv := embeddedName(n.Recv.List[0].Type)
+				if g.methods[recv] == nil {
+					g.methods[recv] = map[string]*ast.FuncType{}
+				}
+				g.methods[recv][n.Name.Name] = n.Type
+			}
+			return true
+		})
+	}
+	return g, nil
+}
+
+// setMode sets the mode of fields not tagged omitempty, required or nullable
+// as for bqschema.WithDefaultMode.
+func (g *generator) setMode(mode string) error {
+	switch mode = strings.ToLower(mode); mode {
+	case "required", "nullable":
+		g.defaultMode = mode
+		return nil
+	default:
+		return fmt.Errorf("invalid mode %q; must be required or nullable", mode)
+	}
+}
+
+// generate returns the formatted source declaring a schema variable for each named type.
+func (g *generator) generate(typeNames []string, args []string) ([]byte, error) {
+	var buf bytes.Buffer
+	fmt.Fprintf(&buf, "// Code generated by \"bqschema %s\"; DO NOT EDIT.\n\n", strings.Join(args, " "))
+	fmt.Fprintf(&buf, "package %s\n\n", g.pkgName)
+	fmt.Fprintf(&buf, "import \"google.golang.org/api/bigquery/v2\"\n")
+
+	for _, name := range typeNames {
+		schema, err := g.schema(name)
+		if err != nil {
+			return nil, fmt.Errorf("%s: %w", name, err)
+		}
+		fmt.Fprintf(&buf, "\n// %sSchema is the BigQuery table schema for %s.\n", name, name)
+		fmt.Fprintf(&buf, "var %sSchema = &bigquery.TableSchema{\n", name)
+		buf.WriteString("Fields: ")
+		writeFields(&buf, schema.Fields)
+		buf.WriteString(",\n}\n")
+	}
+	return format.Source(buf.Bytes())
+}
+
+func writeFields(buf *bytes.Buffer, fields []*bigquery.TableFieldSchema) {
+	buf.WriteString("[]*bigquery.TableFieldSchema{\n")
+	for _, f := range fields {
+		buf.WriteString("&bigquery.TableFieldSchema{\n")
+		fmt.Fprintf(buf, "Mode: %q,\n", f.Mode)
+		fmt.Fprintf(buf, "Name: %q,\n", f.Name)
+		fmt.Fprintf(buf, "Type: %q,\n", f.Type)
+		if f.Description != "" {
+			fmt.Fprintf(buf, "Description: %q,\n", f.Description)
+		}
+		if f.DefaultValueExpression != "" {
+			fmt.Fprintf(buf, "DefaultValueExpression: %q,\n", f.DefaultValueExpression)
+		}
+		if f.RoundingMode != "" {
+			fmt.Fprintf(buf, "RoundingMode: %q,\n", f.RoundingMode)
+		}
+		if f.Collation != "" {
+			fmt.Fprintf(buf, "Collation: %q,\n", f.Collation)
+		}
+		if f.RangeElementType != nil {
+			fmt.Fprintf(buf, "RangeElementType: &bigquery.TableFieldSchemaRangeElementType{Type: %q},\n", f.RangeElementType.Type)
+		}
+		if len(f.Fields) > 0 {
+			buf.WriteString("Fields: ")
+			writeFields(buf, f.Fields)
+			buf.WriteString(",\n")
+		}
+		buf.WriteString("},\n")
+	}
+	buf.WriteString("}")
+}
+
+// schema converts the named type to a BigQuery table schema.
+func (g *generator) schema(name string) (*bigquery.TableSchema, error) {
+	spec, ok := g.types[name]
+	if !ok {
+		return nil, fmt.Errorf("type %s not found", name)
+	}
+	st, _, ok := g.structType(g.indirect(spec.Name))
+	if !ok {
+		return nil, errNotStruct
+	}
+	fields, err := g.structFields(st, name, map[string]bool{})
+	if err != nil {
+		return nil, err
+	}
+	return &bigquery.TableSchema{Fields: fields}, nil
+}
+
+func (g *generator) structFields(st *ast.StructType, name string, seen map[string]bool) ([]*bigquery.TableFieldSchema, error) {
+	if name != "" {
+		if seen[name] {
+			return nil, fmt.Errorf("recursive type %s", name)
+		}
+		seen[name] = true
+		defer delete(seen, name)
+	}
+
+	fields := make([]*bigquery.TableFieldSchema, 0, len(st.Fields.List))
+	for _, f := range st.Fields.List {
+		names := make([]string, 0, len(f.Names))
+		for _, ident := range f.Names {
+			names = append(names, ident.Name)
+		}
+		if len(names) == 0 { // embedded
+			names = append(names, embeddedName(f.Type))
+		}
+
+		for _, fieldName := range names {
+			tag := parseTag(f, fieldName)
+			if tag.skip {
+				continue
+			}
+			if !ast.IsExported(fieldName) {
+				if err := g.checkAccessor(name, fieldName, f.Type); err != nil {
+					return nil, err
+				}
+			}
+			mode := g.defaultMode
+			if tag.nullable {
+				mode = "nullable"
+			}
+
+			var (
+				tfs *bigquery.TableFieldSchema
+				err error
+			)
+			if t, ok := tag.attrs["type"]; ok {
+				tfs, err = g.typedField(tag.name, mode, f.Type, t)
+			} else if values, ok := tag.attrs["enum"]; ok {
+				tfs, err = g.enumField(tag.name, mode, f.Type, strings.Split(values, "|"))
+			} else {
+				tfs, err = g.field(tag.name, mode, f.Type, tag.attrs["as"], seen)
+			}
+			if err != nil {
+				return nil, err
+			}
+			if doc := description(f.Doc); doc != "" {
+				tfs.Description = strings.TrimSpace(doc + " " + tfs.Description)
+			}
+			if err := bqschema.ApplyTags(tfs, fieldTag(f)); err != nil {
+				return nil, fmt.Errorf("%s: %w", tag.name, err)
+			}
+			fields = append(fields, tfs)
+		}
+	}
+	return fields, nil
+}
+
+// field converts a field of type expr; interface types convert as the concrete type named by as.
+func (g *generator) field(name, mode string, expr ast.Expr, as string, seen map[string]bool) (*bigquery.TableFieldSchema, error) {
+	tfs := &bigquery.TableFieldSchema{
+		Mode: mode,
+		Name: name,
+	}
+
+	if t, err := g.methodType(expr, as); err != nil || t != "" {
+		tfs.Type = t
+		return tfs, err
+	}
+	expr = g.concrete(g.indirect(expr), as)
+	if t, ok := simpleType(expr); ok {
+		tfs.Type = t
+		return tfs, nil
+	}
+	if isTime(expr) {
+		tfs.Mode = "nullable"
+		tfs.Type = "timestamp"
+		return tfs, nil
+	}
+
+	if at, ok := expr.(*ast.ArrayType); ok {
+		tfs.Mode = "repeated"
+		if t, err := g.methodType(at.Elt, as); err != nil || t != "" {
+			tfs.Type = t
+			return tfs, err
+		}
+		elt := g.concrete(g.indirect(at.Elt), as)
+		if t, ok := simpleType(elt); ok {
+			tfs.Type = t
+			return tfs, nil
+		}
+		if isTime(elt) {
+			tfs.Type = "timestamp"
+			return tfs, nil
+		}
+		st, typeName, ok := g.structType(elt)
+		if !ok {
+			return nil, errArrayOfArray
+		}
+		fields, err := g.structFields(st, typeName, seen)
+		if err != nil {
+			return nil, err
+		}
+		tfs.Type = "record"
+		tfs.Fields = fields
+		return tfs, nil
+	}
+
+	if mt, ok := expr.(*ast.MapType); ok {
+		if t, ok := simpleType(g.indirect(mt.Key)); !ok || (t != "string" && t != "integer") {
+			return nil, fmt.Errorf("inconvertible type: %s", types.ExprString(expr))
+		}
+		key, err := g.field("key", g.defaultMode, mt.Key, "", seen)
+		if err != nil {
+			return nil, err
+		}
+		value, err := g.field("value", g.defaultMode, mt.Value, as, seen)
+		if err != nil {
+			return nil, err
+		}
+		tfs.Mode = "repeated"
+		tfs.Type = "record"
+		tfs.Fields = []*bigquery.TableFieldSchema{key, value}
+		return tfs, nil
+	}
+
+	if st, typeName, ok := g.structType(expr); ok {
+		fields, err := g.structFields(st, typeName, seen)
+		if err != nil {
+			return nil, err
+		}
+		tfs.Mode = "nullable"
+		tfs.Type = "record"
+		tfs.Fields = fields
+		return tfs, nil
+	}
+
+	return nil, fmt.Errorf("inconvertible type: %s", types.ExprString(expr))
+}
+
+// enumField converts a field declaring the values it holds with an enum
+// attribute to a string column, repeated for arrays, listing them in its
+// description.
+// enumField converts a field tagged with the enum attribute, which must be of
+// a string kind or of a type with a String method, as ToSchema requires.
+func (g *generator) enumField(name, mode string, expr ast.Expr, values []string) (*bigquery.TableFieldSchema, error) {
+	elem := expr
+	if at, ok := g.indirect(expr).(*ast.ArrayType); ok {
+		mode = "repeated"
+		elem = at.Elt
+	}
+	for {
+		if star, ok := elem.(*ast.StarExpr); ok {
+			elem = star.X
+			continue
+		}
+		break
+	}
+	if id, ok := elem.(*ast.Ident); !ok || !g.returnsString(id.Name, "String") {
+		if id, ok := g.indirect(elem).(*ast.Ident); !ok || id.Name != "string" {
+			return nil, fmt.Errorf("enum field %s requires a string type or a String method", name)
+		}
+	}
+	return &bigquery.TableFieldSchema{
+		Description: fmt.Sprintf("One of: %s.", strings.Join(values, ", ")),
+		Mode:        mode,
+		Name:        name,
+		Type:        "string",
+	}, nil
+}
+
+// methodType returns the column type decided by the methods of the package
+// type expr names through pointers, or as names for interfaces, as ToSchema
+// does: types with String() string and Encode() string methods, such as keys,
+// convert to string columns. Types implementing bqschema.Enum are rejected as
+// their values are only known at runtime.
+func (g *generator) methodType(expr ast.Expr, as string) (string, error) {
+	if as != "" && isInterface(g.indirect(expr)) {
+		expr = ast.NewIdent(as)
+	}
+	for {
+		switch e := expr.(type) {
+		case *ast.StarExpr:
+			expr = e.X
+		case *ast.ParenExpr:
+			expr = e.X
+		case *ast.Ident:
+			if _, ok := g.methods[e.Name]["BigQueryEnum"]; ok {
+				return "", fmt.Errorf("enum type %s: declare its values with the enum attribute", e.Name)
+			}
+			if g.returnsString(e.Name, "String") && g.returnsString(e.Name, "Encode") {
+				return "string", nil
+			}
+			return "", nil
+		default:
+			return "", nil
+		}
+	}
+}
+
+// returnsString reports whether the package type typeName has a method taking
+// no arguments and returning a string.
+func (g *generator) returnsString(typeName, method string) bool {
+	fn, ok := g.methods[typeName][method]
+	return ok && fn.Params.NumFields() == 0 && fn.Results.NumFields() == 1 && types.ExprString(fn.Results.List[0].Type) == "string"
+}
+
+// checkAccessor verifies that the struct type typeName has the accessor
+// method of its exported unexported field fieldName of type expr.
+func (g *generator) checkAccessor(typeName, fieldName string, expr ast.Expr) error {
+	accessor := strings.ToUpper(fieldName[:1]) + fieldName[1:]
+	fn, ok := g.methods[typeName][accessor]
+	if !ok || fn.Params.NumFields() != 0 || fn.Results.NumFields() != 1 || types.ExprString(fn.Results.List[0].Type) != types.ExprString(expr) {
+		return fmt.Errorf("exported field %s of %s requires a method %s() %s", fieldName, typeName, accessor, types.ExprString(expr))
+	}
+	return nil
+}
+
+// typedField converts a field whose column type is declared by a type attribute.
+func (g *generator) typedField(name, mode string, expr ast.Expr, t string) (*bigquery.TableFieldSchema, error) {
+	tfs := &bigquery.TableFieldSchema{
+		Mode: mode,
+		Name: name,
+		Type: strings.ToLower(t),
+	}
+	if at, ok := g.indirect(expr).(*ast.ArrayType); ok {
+		if elt, ok := g.indirect(at.Elt).(*ast.Ident); !ok || (elt.Name != "byte" && elt.Name != "uint8") {
+			tfs.Mode = "repeated"
+		}
+	}
+
+	if i := strings.Index(tfs.Type, "<"); i >= 0 {
+		element := strings.TrimSuffix(tfs.Type[i+1:], ">")
+		if tfs.Type[:i] != "range" || !strings.HasSuffix(tfs.Type, ">") {
+			return nil, fmt.Errorf("invalid type %q for field %s", t, name)
+		}
+		switch element {
+		case "date", "datetime", "timestamp":
+		default:
+			return nil, fmt.Errorf("invalid range element type %q for field %s", element, name)
+		}
+		tfs.Type = "range"
+		tfs.RangeElementType = &bigquery.TableFieldSchemaRangeElementType{Type: element}
+	} else if tfs.Type == "range" {
+		return nil, fmt.Errorf("range type for field %s requires an element type", name)
+	}
+	return tfs, nil
+}
+
+// indirect strips pointers and follows named non-struct types declared in the package
+// to their underlying type expression.
+func (g *generator) indirect(expr ast.Expr) ast.Expr {
+	for i := 0; i < len(g.types)+1; i++ {
+		switch e := expr.(type) {
+		case *ast.StarExpr:
+			expr = e.X
+		case *ast.ParenExpr:
+			expr = e.X
+		case *ast.Ident:
+			spec, ok := g.types[e.Name]
+			if !ok {
+				return expr
+			}
+			if _, ok := spec.Type.(*ast.StructType); ok {
+				return expr
+			}
+			expr = spec.Type
+		default:
+			return expr
+		}
+	}
+	return expr
+}
+
+// concrete replaces an interface type with the package type named by as.
+func (g *generator) concrete(expr ast.Expr, as string) ast.Expr {
+	if as == "" || !isInterface(expr) {
+		return expr
+	}
+	return g.indirect(ast.NewIdent(as))
+}
+
+func isInterface(expr ast.Expr) bool {
+	switch e := expr.(type) {
+	case *ast.InterfaceType:
+		return true
+	case *ast.Ident:
+		return e.Name == "any"
+	default:
+		return false
+	}
+}
+
+// structType reports the struct type for expr and its declared name, if any.
+func (g *generator) structType(expr ast.Expr) (*ast.StructType, string, bool) {
+	switch e := expr.(type) {
+	case *ast.StructType:
+		return e, "", true
+	case *ast.Ident:
+		if spec, ok := g.types[e.Name]; ok {
+			if st, ok := spec.Type.(*ast.StructType); ok {
+				return st, e.Name, true
+			}
+		}
+	}
+	return nil, "", false
+}
+
+func simpleType(expr ast.Expr) (string, bool) {
+	ident, ok := expr.(*ast.Ident)
+	if !ok {
+		return "", false
+	}
+	switch ident.Name {
+	case "bool":
+		return "boolean", true
+	case "int", "int8", "int16", "int32", "int64", "uint", "uint8", "uint16", "uint32", "uint64", "byte", "rune":
+		return "integer", true
+	case "float32", "float64":
+		return "float", true
+	case "string":
+		return "string", true
+	default:
+		return "", false
+	}
+}
+
+func isTime(expr ast.Expr) bool {
+	sel, ok := expr.(*ast.SelectorExpr)
+	if !ok {
+		return false
+	}
+	pkg, ok := sel.X.(*ast.Ident)
+	return ok && pkg.Name == "time" && sel.Sel.Name == "Time"
+}
+
+func embeddedName(expr ast.Expr) string {
+	switch e := expr.(type) {
+	case *ast.StarExpr:
+		return embeddedName(e.X)
+	case *ast.IndexExpr:
+		return embeddedName(e.X)
+	case *ast.IndexListExpr:
+		return embeddedName(e.X)
+	case *ast.SelectorExpr:
+		return e.Sel.Name
+	case *ast.Ident:
+		return e.Name
+	default:
+		return ""
+	}
+}
+
+// description flattens a field's doc comment into a single line column description.
+func description(doc *ast.CommentGroup) string {
+	if doc == nil {
+		return ""
+	}
+	return strings.Join(strings.Fields(doc.Text()), " ")
+}
+
+// tag is the column naming and options declared by a field's json and bigquery tags.
+type tag struct {
+	name     string
+	skip     bool
+	nullable bool
+	attrs    map[string]string
+}
+
+func parseTag(f *ast.Field, name string) tag {
+	st := fieldTag(f)
+	t := tag{name: name, attrs: map[string]string{}}
+
+	if !ast.IsExported(name) && !hasOption(st.Get("bqschema"), "export") {
+		t.skip = true
+		return t
+	}
+
+	named := false
+	if bqTag := st.Get("bigquery"); bqTag == "-" {
+		t.skip = true
+		return t
+	} else if bqTag != "" {
+		bt := strings.Split(bqTag, ",")
+		if bt[0] != "" {
+			t.name = bt[0]
+			named = true
+		}
+		for _, attr := range bt[1:] {
+			kv := strings.SplitN(attr, "=", 2)
+			if len(kv) == 2 {
+				t.attrs[kv[0]] = kv[1]
+			} else {
+				t.attrs[kv[0]] = ""
+			}
+		}
+	}
+
+	// The bigquery tag takes priority: a json tag of "-" only skips fields it
+	// does not name.
+	if jsonTag := st.Get("json"); jsonTag == "-" && !named {
+		t.skip = true
+	} else if jsonTag != "" && jsonTag != "-" {
+		jt := strings.Split(jsonTag, ",")
+		if jt[0] != "" && !named {
+			t.name = jt[0]
+		}
+		for _, opt := range jt[1:] {
+			if opt == "omitempty" || opt == "omitzero" {
+				t.nullable = true
+			}
+		}
+	}
+	return t
+}
+
+func hasOption(tag, option string) bool {
+	for _, opt := range strings.Split(tag, ",") {
+		if opt == option {
+			return true
+		}
+	}
+	return false
+}
+
+func fieldTag(f *ast.Field) reflect.StructTag {
+	if f.Tag == nil {
+		return ""
+	}
+	tag, err := strconv.Unquote(f.Tag.Value)
+	if err != nil {
+		return ""
+	}
+	return reflect.StructTag(tag)
+}
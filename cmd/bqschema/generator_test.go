@@ -0,0 +1,310 @@
+package main
+
+import (
+	"go/parser"
+	"go/token"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+
+	"github.com/nbio/bqschema"
+	"github.com/nbio/bqschema/cmd/bqschema/internal/golden"
+	"google.golang.org/api/bigquery/v2"
+)
+
+const testSource = `package events
+
+import "time"
+
+type UserID int64
+
+type Event struct {
+	ID       UserID
+	// Name is the display name
+	// of the event.
+	Name     string ` + "`json:\"name,omitempty\"`" + `
+	Skipped  string ` + "`json:\"-\"`" + `
+	hidden   string
+	At       time.Time
+	Tags     []string
+	Score    *float64
+	Location Location
+	Items    []*Item
+}
+
+type Location struct {
+	Lat, Lng float64
+}
+
+type Item struct {
+	Count int
+	sku   string ` + "`bqschema:\"export\"`" + `
+}
+
+func (i *Item) Sku() string { return i.sku }
+
+type NoAccessor struct {
+	sku string ` + "`bqschema:\"export\"`" + `
+}
+
+func (n NoAccessor) Sku() int { return 0 }
+
+type Level int
+
+func (Level) BigQueryEnum() []string { return []string{"low", "high"} }
+
+func (l Level) String() string { return Level.BigQueryEnum(l)[l] }
+
+type Alert struct {
+	Level Level
+}
+
+type Ticket struct {
+	Level Level ` + "`bigquery:\",enum=low|high\"`" + `
+}
+
+type Node struct {
+	Children []Node
+}
+
+type Bad struct {
+	Matrix [][]int
+}
+
+type Shape interface {
+	Area() float64
+}
+
+type Drawing struct {
+	Main   Shape   ` + "`bigquery:\"main,as=Location\"`" + `
+	Others []Shape ` + "`bigquery:\",as=Location\"`" + `
+	Hidden string  ` + "`bigquery:\"-\"`" + `
+}
+
+type Label = string
+
+type Account struct {
+	Owners []UserID
+	Labels []Label
+	Scores map[Label]*UserID
+	Visits map[int64][]Location
+}
+
+type BadKey struct {
+	Flags map[bool]int
+}
+
+type Booking struct {
+	Stay  string   ` + "`bigquery:\",type=RANGE<DATE>\"`" + `
+	Blob  []byte   ` + "`bigquery:\",type=bytes\"`" + `
+	Days  []string ` + "`bigquery:\",type=date\"`" + `
+	Guest string   ` + "`bigquery:\",collation=und:ci\" description:\"Lead guest.\"`" + `
+	Price string   ` + "`bigquery:\",type=numeric,roundingMode=round_half_even\"`" + `
+}
+
+type BadCollation struct {
+	Count int ` + "`bigquery:\",collation=und:ci\"`" + `
+}
+
+type BadRounding struct {
+	Price string ` + "`bigquery:\",type=numeric,roundingMode=up\"`" + `
+}
+
+type BadEnum struct {
+	Code int ` + "`bigquery:\",enum=1|2\"`" + `
+}
+
+type BadDefault struct {
+	Count int ` + "`default:\"'a'\"`" + `
+}
+`
+
+var _ = Describe("generator", func() {
+	var (
+		dir string
+		g   *generator
+	)
+
+	BeforeEach(func() {
+		var err error
+		dir, err = ioutil.TempDir("", "bqschema")
+		Expect(err).To(BeNil())
+		Expect(ioutil.WriteFile(filepath.Join(dir, "events.go"), []byte(testSource), 0644)).To(Succeed())
+		g, err = newGenerator(dir)
+		Expect(err).To(BeNil())
+	})
+
+	AfterEach(func() {
+		os.RemoveAll(dir)
+	})
+
+	It("should convert struct declarations the same way ToSchema converts values", func() {
+		schema, err := g.schema("Event")
+		Expect(err).To(BeNil())
+		Expect(*schema).To(Equal(bigquery.TableSchema{
+			Fields: []*bigquery.TableFieldSchema{
+				&bigquery.TableFieldSchema{Mode: "required", Name: "ID", Type: "integer"},
+				&bigquery.TableFieldSchema{Mode: "nullable", Name: "name", Type: "string", Description: "Name is the display name of the event."},
+				&bigquery.TableFieldSchema{Mode: "nullable", Name: "At", Type: "timestamp"},
+				&bigquery.TableFieldSchema{Mode: "repeated", Name: "Tags", Type: "string"},
+				&bigquery.TableFieldSchema{Mode: "required", Name: "Score", Type: "float"},
+				&bigquery.TableFieldSchema{
+					Mode: "nullable",
+					Name: "Location",
+					Type: "record",
+					Fields: []*bigquery.TableFieldSchema{
+						&bigquery.TableFieldSchema{Mode: "required", Name: "Lat", Type: "float"},
+						&bigquery.TableFieldSchema{Mode: "required", Name: "Lng", Type: "float"},
+					},
+				},
+				&bigquery.TableFieldSchema{
+					Mode: "repeated",
+					Name: "Items",
+					Type: "record",
+					Fields: []*bigquery.TableFieldSchema{
+						&bigquery.TableFieldSchema{Mode: "required", Name: "Count", Type: "integer"},
+						&bigquery.TableFieldSchema{Mode: "required", Name: "sku", Type: "string"},
+					},
+				},
+			},
+		}))
+	})
+
+	It("should resolve named scalar types and aliases and convert maps to key and value records", func() {
+		schema, err := g.schema("Account")
+		Expect(err).To(BeNil())
+		location := []*bigquery.TableFieldSchema{
+			&bigquery.TableFieldSchema{Mode: "required", Name: "Lat", Type: "float"},
+			&bigquery.TableFieldSchema{Mode: "required", Name: "Lng", Type: "float"},
+		}
+		Expect(schema.Fields).To(Equal([]*bigquery.TableFieldSchema{
+			&bigquery.TableFieldSchema{Mode: "repeated", Name: "Owners", Type: "integer"},
+			&bigquery.TableFieldSchema{Mode: "repeated", Name: "Labels", Type: "string"},
+			&bigquery.TableFieldSchema{Mode: "repeated", Name: "Scores", Type: "record", Fields: []*bigquery.TableFieldSchema{
+				&bigquery.TableFieldSchema{Mode: "required", Name: "key", Type: "string"},
+				&bigquery.TableFieldSchema{Mode: "required", Name: "value", Type: "integer"},
+			}},
+			&bigquery.TableFieldSchema{Mode: "repeated", Name: "Visits", Type: "record", Fields: []*bigquery.TableFieldSchema{
+				&bigquery.TableFieldSchema{Mode: "required", Name: "key", Type: "integer"},
+				&bigquery.TableFieldSchema{Mode: "repeated", Name: "value", Type: "record", Fields: location},
+			}},
+		}))
+
+		_, err = g.schema("BadKey")
+		Expect(err).To(MatchError("inconvertible type: map[bool]int"))
+	})
+
+	It("should use the default mode for fields not tagged omitempty", func() {
+		g.defaultMode = "nullable"
+		schema, err := g.schema("Item")
+		Expect(err).To(BeNil())
+		Expect(schema.Fields[0].Mode).To(Equal("nullable"))
+	})
+
+	It("should convert interface fields as the concrete type named by their bigquery tag", func() {
+		schema, err := g.schema("Drawing")
+		Expect(err).To(BeNil())
+		location := []*bigquery.TableFieldSchema{
+			&bigquery.TableFieldSchema{Mode: "required", Name: "Lat", Type: "float"},
+			&bigquery.TableFieldSchema{Mode: "required", Name: "Lng", Type: "float"},
+		}
+		Expect(schema.Fields).To(Equal([]*bigquery.TableFieldSchema{
+			&bigquery.TableFieldSchema{Mode: "nullable", Name: "main", Type: "record", Fields: location},
+			&bigquery.TableFieldSchema{Mode: "repeated", Name: "Others", Type: "record", Fields: location},
+		}))
+	})
+
+	It("should convert fields with declared column types", func() {
+		schema, err := g.schema("Booking")
+		Expect(err).To(BeNil())
+		Expect(schema.Fields).To(Equal([]*bigquery.TableFieldSchema{
+			&bigquery.TableFieldSchema{
+				Mode:             "required",
+				Name:             "Stay",
+				Type:             "range",
+				RangeElementType: &bigquery.TableFieldSchemaRangeElementType{Type: "date"},
+			},
+			&bigquery.TableFieldSchema{Mode: "required", Name: "Blob", Type: "bytes"},
+			&bigquery.TableFieldSchema{Mode: "repeated", Name: "Days", Type: "date"},
+			&bigquery.TableFieldSchema{Mode: "required", Name: "Guest", Type: "string", Collation: "und:ci", Description: "Lead guest."},
+			&bigquery.TableFieldSchema{Mode: "required", Name: "Price", Type: "numeric", RoundingMode: "ROUND_HALF_EVEN"},
+		}))
+
+		src, err := g.generate([]string{"Booking"}, nil)
+		Expect(err).To(BeNil())
+		Expect(string(src)).To(ContainSubstring(`RangeElementType: &bigquery.TableFieldSchemaRangeElementType{Type: "date"},`))
+		Expect(string(src)).To(MatchRegexp(`Collation:\s+"und:ci",`))
+		Expect(string(src)).To(MatchRegexp(`RoundingMode:\s+"ROUND_HALF_EVEN",`))
+	})
+
+	It("should check column options as ToSchema does", func() {
+		_, err := g.schema("BadCollation")
+		Expect(err).To(MatchError("Count: collation not allowed for integer column"))
+		_, err = g.schema("BadRounding")
+		Expect(err).To(MatchError(`Price: invalid rounding mode "UP"`))
+		_, err = g.schema("BadDefault")
+		Expect(err).To(MatchError("Count: default 'a' not allowed for integer column"))
+		_, err = g.schema("BadEnum")
+		Expect(err).To(MatchError("enum field Code requires a string type or a String method"))
+	})
+
+	It("should check accessors of exported unexported fields", func() {
+		_, err := g.schema("Item")
+		Expect(err).To(BeNil())
+		_, err = g.schema("NoAccessor")
+		Expect(err).To(MatchError("exported field sku of NoAccessor requires a method Sku() string"))
+	})
+
+	It("should reject enum types and convert enum attributes", func() {
+		_, err := g.schema("Alert")
+		Expect(err).To(MatchError("enum type Level: declare its values with the enum attribute"))
+		schema, err := g.schema("Ticket")
+		Expect(err).To(BeNil())
+		Expect(schema.Fields).To(Equal([]*bigquery.TableFieldSchema{
+			&bigquery.TableFieldSchema{Mode: "required", Name: "Level", Type: "string", Description: "One of: low, high."},
+		}))
+	})
+
+	It("should accept the modes WithDefaultMode accepts", func() {
+		Expect(g.setMode("NULLABLE")).To(Succeed())
+		Expect(g.defaultMode).To(Equal("nullable"))
+		Expect(g.setMode("repeated")).To(MatchError(`invalid mode "repeated"; must be required or nullable`))
+	})
+
+	It("should generate the schemas ToSchema returns", func() {
+		g, err := newGenerator(filepath.Join("internal", "golden"))
+		Expect(err).To(BeNil())
+		for name, src := range map[string]interface{}{"Event": golden.Event{}, "Item": golden.Item{}} {
+			schema, err := g.schema(name)
+			Expect(err).To(BeNil(), name)
+			Expect(schema).To(Equal(bqschema.MustToSchema(src, bqschema.WithConcreteType("Location", golden.Location{}))), name)
+		}
+	})
+
+	It("should reject unknown, non struct, recursive and nested array types", func() {
+		_, err := g.schema("Missing")
+		Expect(err).NotTo(BeNil())
+		_, err = g.schema("UserID")
+		Expect(err).To(Equal(errNotStruct))
+		_, err = g.schema("Node")
+		Expect(err).NotTo(BeNil())
+		_, err = g.schema("Bad")
+		Expect(err).To(Equal(errArrayOfArray))
+	})
+
+	It("should generate a valid Go file declaring the schema variables", func() {
+		src, err := g.generate([]string{"Event", "Item"}, []string{"-type=Event,Item"})
+		Expect(err).To(BeNil())
+		Expect(string(src)).To(HavePrefix("// Code generated by \"bqschema -type=Event,Item\"; DO NOT EDIT.\n"))
+		Expect(string(src)).To(ContainSubstring("var EventSchema = &bigquery.TableSchema{"))
+		Expect(string(src)).To(ContainSubstring("var ItemSchema = &bigquery.TableSchema{"))
+		Expect(string(src)).To(ContainSubstring(`Description: "Name is the display name of the event.",`))
+
+		f, err := parser.ParseFile(token.NewFileSet(), "", src, 0)
+		Expect(err).To(BeNil())
+		Expect(f.Name.Name).To(Equal("events"))
+	})
+})
@@ -0,0 +1,57 @@
+// Package golden declares types converted both by the bqschema command and by
+// bqschema.ToSchema, for tests to check that the schemas are the same.
+package golden
+
+import "time"
+
+type UserID int64
+
+// Key is an identifier encoded as a string.
+type Key struct {
+	id int64
+}
+
+func (k *Key) String() string { return k.Encode() }
+func (k *Key) Encode() string { return "" }
+
+type Location struct {
+	Lat, Lng float64
+}
+
+type Shape interface {
+	Area() float64
+}
+
+type Item struct {
+	Count int    `json:"count"`
+	SKU   string `bigquery:"sku,collation=und:ci" description:"Stock keeping unit."`
+}
+
+type Event struct {
+	ID       UserID `json:"id"`
+	Name     string `json:"name,omitempty"`
+	Skipped  string `json:"-"`
+	Internal string `bigquery:"internal" json:"-"`
+	Hidden   string `bigquery:"-"`
+	hidden   string
+	score    float64              `bqschema:"export"`
+	At       time.Time            `default:"CURRENT_TIMESTAMP()"`
+	Tags     []string             `json:"tags"`
+	Ratio    *float64             `json:"ratio,omitempty"`
+	Location Location             `json:"location"`
+	Items    []*Item              `json:"items"`
+	Owner    *Key                 `json:"owner"`
+	Editors  []Key                `json:"editors"`
+	Counts   map[string]int64     `json:"counts"`
+	Places   map[int64][]Location `json:"places"`
+	Main     Shape                `bigquery:"main,as=Location"`
+	Status   string               `bigquery:",enum=open|closed"`
+	Labels   []string             `bigquery:",enum=a|b"`
+	Stay     string               `bigquery:",type=RANGE<DATE>"`
+	Price    string               `bigquery:",type=NUMERIC,roundingMode=round_half_even"`
+	Email    string               `redact:"sha256"`
+	Phone    string               `redact:"truncate=4" description:"Contact number."`
+	Payload  []byte               `bigquery:",type=bytes"`
+}
+
+func (e *Event) Score() float64 { return e.score }
@@ -0,0 +1,91 @@
+// Command bqschema generates static BigQuery table schemas for Go struct types.
+//
+// It is designed to be run by go generate:
+//
+//	//go:generate bqschema -type=Event
+//
+// which writes event_bqschema.go, declaring
+//
+//	var EventSchema = &bigquery.TableSchema{...}
+//
+// The generated schema is the one bqschema.ToSchema returns with no options
+// for types declared in the package, but is computed from the package source
+// so no reflection happens at runtime. Column options declared by tags are
+// checked as ToSchema checks them. Field doc comments, which reflection can
+// never see, become column descriptions, following any description tag.
+//
+// What is only known at runtime can not be seen from the source:
+//
+//   - types registered with bqschema.RegisterTypeMapping or
+//     bqschema.RegisterStringType and tags registered with
+//     bqschema.RegisterJSONOption are ignored; types with String and
+//     Encode() string methods still convert to string columns;
+//   - the as attribute names a type declared in the package rather than one
+//     registered with bqschema.RegisterConcreteType;
+//   - types implementing bqschema.Enum are rejected; declare their values with
+//     the enum attribute instead;
+//   - only types declared in the package and time.Time convert.
+//
+// The -mode flag sets the mode of fields not tagged omitempty, required or
+// nullable, like bqschema.WithDefaultMode.
+package main
+
+import (
+	"flag"
+	"fmt"
+	"io/ioutil"
+	"log"
+	"os"
+	"path/filepath"
+	"strings"
+)
+
+var (
+	typeNames = flag.String("type", "", "comma-separated list of type names; must be set")
+	output    = flag.String("output", "", "output file name; default srcdir/<type>_bqschema.go")
+	mode      = flag.String("mode", "required", "mode of fields not tagged omitempty: required or nullable")
+)
+
+func usage() {
+	fmt.Fprintf(os.Stderr, "Usage of bqschema:\n")
+	fmt.Fprintf(os.Stderr, "\tbqschema [flags] -type T [directory]\n")
+	fmt.Fprintf(os.Stderr, "Flags:\n")
+	flag.PrintDefaults()
+}
+
+func main() {
+	log.SetFlags(0)
+	log.SetPrefix("bqschema: ")
+	flag.Usage = usage
+	flag.Parse()
+	if *typeNames == "" {
+		flag.Usage()
+		os.Exit(2)
+	}
+	types := strings.Split(*typeNames, ",")
+
+	dir := "."
+	if args := flag.Args(); len(args) > 0 {
+		dir = args[0]
+	}
+
+	g, err := newGenerator(dir)
+	if err != nil {
+		log.Fatal(err)
+	}
+	if err := g.setMode(*mode); err != nil {
+		log.Fatal(err)
+	}
+	src, err := g.generate(types, os.Args[1:])
+	if err != nil {
+		log.Fatal(err)
+	}
+
+	outputName := *output
+	if outputName == "" {
+		outputName = filepath.Join(dir, strings.ToLower(types[0])+"_bqschema.go")
+	}
+	if err := ioutil.WriteFile(outputName, src, 0644); err != nil {
+		log.Fatalf("writing output: %s", err)
+	}
+}
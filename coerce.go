@@ -0,0 +1,205 @@
+package bqschema
+
+import (
+	"encoding/base64"
+	"encoding/json"
+	"fmt"
+	"math"
+	"reflect"
+	"strconv"
+	"strings"
+	"time"
+
+	"google.golang.org/api/bigquery/v2"
+)
+
+// maxSafeInteger is the largest integer a JSON number can hold without losing precision.
+const maxSafeInteger = 1<<53 - 1
+
+// CoerceRow converts the values of row into the JSON representations BigQuery
+// accepts for the columns of schema: RFC3339 timestamps, base64 bytes, string
+// encoded numerics and string encoded integers beyond the range of a JSON number.
+// Null values are omitted. The returned error, if any, is a ValidationErrors,
+// or ErrNilSchema if schema is nil.
+func CoerceRow(schema *bigquery.TableSchema, row map[string]interface{}) (map[string]bigquery.JsonValue, error) {
+	if schema == nil {
+		return nil, ErrNilSchema
+	}
+	var errs ValidationErrors
+	result := coerceRecord(schema.Fields, reflect.ValueOf(row), "", &errs)
+	if len(errs) > 0 {
+		return nil, errs
+	}
+	return result, nil
+}
+
+func coerceRecord(fields []*bigquery.TableFieldSchema, row reflect.Value, prefix string, errs *ValidationErrors) map[string]bigquery.JsonValue {
+	result := make(map[string]bigquery.JsonValue, row.Len())
+	known := make(map[string]bool, len(fields))
+	for _, field := range fields {
+		known[strings.ToLower(field.Name)] = true
+		v, ok := lookupValue(row, field.Name)
+		if !ok {
+			continue
+		}
+		if value, ok := coerceField(field, v, prefix+field.Name, errs); ok {
+			result[field.Name] = value
+		}
+	}
+
+	for _, key := range row.MapKeys() {
+		if !known[strings.ToLower(key.String())] {
+			*errs = append(*errs, &ValidationError{prefix + key.String(), "no such field in schema"})
+		}
+	}
+	return result
+}
+
+func coerceField(field *bigquery.TableFieldSchema, v reflect.Value, path string, errs *ValidationErrors) (bigquery.JsonValue, bool) {
+	if !strings.EqualFold(field.Mode, "repeated") {
+		return coerceScalar(field, v, path, errs)
+	}
+
+	if v.Kind() != reflect.Slice && v.Kind() != reflect.Array {
+		*errs = append(*errs, &ValidationError{path, fmt.Sprintf("repeated field requires an array, got %s", v.Type())})
+		return nil, false
+	}
+	values := make([]bigquery.JsonValue, 0, v.Len())
+	for i := 0; i < v.Len(); i++ {
+		elemPath := fmt.Sprintf("%s[%d]", path, i)
+		elem := indirectValue(v.Index(i))
+		if !elem.IsValid() {
+			*errs = append(*errs, &ValidationError{elemPath, "repeated field can not contain null"})
+			continue
+		}
+		if value, ok := coerceScalar(field, elem, elemPath, errs); ok {
+			values = append(values, value)
+		}
+	}
+	return values, true
+}
+
+func coerceScalar(field *bigquery.TableFieldSchema, v reflect.Value, path string, errs *ValidationErrors) (bigquery.JsonValue, bool) {
+	if strings.EqualFold(field.Type, "record") || strings.EqualFold(field.Type, "struct") {
+		if v.Kind() != reflect.Map || v.Type().Key().Kind() != reflect.String {
+			*errs = append(*errs, &ValidationError{path, fmt.Sprintf("record requires a map, got %s", v.Type())})
+			return nil, false
+		}
+		return coerceRecord(field.Fields, v, path+".", errs), true
+	}
+
+	value, err := coerceValue(field.Type, v)
+	if err != nil {
+		*errs = append(*errs, &ValidationError{path, err.Error()})
+		return nil, false
+	}
+	return value, true
+}
+
+// integerRange returns an error if v, of an unsigned integer or float kind,
+// is out of the range of INTEGER columns.
+func integerRange(v reflect.Value) error {
+	switch v.Kind() {
+	case reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64:
+		if v.Uint() > math.MaxInt64 {
+			return fmt.Errorf("%d overflows integer", v.Uint())
+		}
+	case reflect.Float32, reflect.Float64:
+		// float64(math.MaxInt64) rounds up to 2^63, itself out of range.
+		if f := v.Float(); math.IsNaN(f) || math.IsInf(f, 0) || f >= 9223372036854775808.0 || f < math.MinInt64 {
+			return fmt.Errorf("%g overflows integer", f)
+		}
+	}
+	return nil
+}
+
+func coerceValue(bqType string, v reflect.Value) (bigquery.JsonValue, error) {
+	if n, ok := v.Interface().(json.Number); ok {
+		v = reflect.ValueOf(n.String())
+	}
+	if !coercible(bqType, v) {
+		return nil, fmt.Errorf("can not coerce %s to %s", v.Type(), strings.ToLower(bqType))
+	}
+	kind := v.Kind()
+
+	switch strings.ToLower(bqType) {
+	case "string":
+		if kind != reflect.String {
+			return v.Interface().(fmt.Stringer).String(), nil
+		}
+	case "integer", "int64":
+		switch kind {
+		case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64:
+			return coerceInt(v.Int()), nil
+		case reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64:
+			if err := integerRange(v); err != nil {
+				return nil, err
+			}
+			return coerceInt(int64(v.Uint())), nil
+		case reflect.Float32, reflect.Float64:
+			if err := integerRange(v); err != nil {
+				return nil, err
+			}
+			return coerceInt(int64(v.Float())), nil
+		}
+	case "float", "float64":
+		switch kind {
+		case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64:
+			return float64(v.Int()), nil
+		case reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64:
+			return float64(v.Uint()), nil
+		case reflect.Float32, reflect.Float64:
+			return v.Float(), nil
+		}
+	case "numeric", "bignumeric":
+		switch kind {
+		case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64:
+			return strconv.FormatInt(v.Int(), 10), nil
+		case reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64:
+			return strconv.FormatUint(v.Uint(), 10), nil
+		case reflect.Float32, reflect.Float64:
+			return strconv.FormatFloat(v.Float(), 'f', -1, 64), nil
+		}
+	case "boolean", "bool":
+		if kind == reflect.String {
+			b, _ := strconv.ParseBool(v.String())
+			return b, nil
+		}
+		return v.Bool(), nil
+	case "timestamp", "datetime", "date", "time":
+		if kind != reflect.String {
+			t := v.Convert(reflect.TypeOf(time.Time{})).Interface().(time.Time)
+			return formatTime(bqType, t), nil
+		}
+	case "bytes":
+		if kind != reflect.String {
+			return base64.StdEncoding.EncodeToString(v.Bytes()), nil
+		}
+	}
+
+	if kind == reflect.String {
+		return v.String(), nil
+	}
+	return v.Interface(), nil
+}
+
+// coerceInt keeps integers as JSON numbers unless they would lose precision.
+func coerceInt(i int64) bigquery.JsonValue {
+	if i > maxSafeInteger || i < -maxSafeInteger {
+		return strconv.FormatInt(i, 10)
+	}
+	return i
+}
+
+func formatTime(bqType string, t time.Time) string {
+	switch strings.ToLower(bqType) {
+	case "datetime":
+		return t.Format("2006-01-02T15:04:05.999999")
+	case "date":
+		return t.Format("2006-01-02")
+	case "time":
+		return t.Format("15:04:05.999999")
+	default:
+		return t.UTC().Format(time.RFC3339Nano)
+	}
+}
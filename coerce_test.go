@@ -0,0 +1,94 @@
+package bqschema
+
+import (
+	"fmt"
+	"math"
+	"time"
+
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+
+	"google.golang.org/api/bigquery/v2"
+)
+
+var _ = Describe("CoerceRow", func() {
+	schema := &bigquery.TableSchema{
+		Fields: []*bigquery.TableFieldSchema{
+			&bigquery.TableFieldSchema{Mode: "required", Name: "Small", Type: "integer"},
+			&bigquery.TableFieldSchema{Mode: "required", Name: "Large", Type: "integer"},
+			&bigquery.TableFieldSchema{Mode: "nullable", Name: "At", Type: "timestamp"},
+			&bigquery.TableFieldSchema{Mode: "nullable", Name: "Day", Type: "date"},
+			&bigquery.TableFieldSchema{Mode: "nullable", Name: "Blob", Type: "bytes"},
+			&bigquery.TableFieldSchema{Mode: "nullable", Name: "Price", Type: "numeric"},
+			&bigquery.TableFieldSchema{Mode: "repeated", Name: "Flags", Type: "boolean"},
+			&bigquery.TableFieldSchema{
+				Mode: "nullable",
+				Name: "Sub",
+				Type: "record",
+				Fields: []*bigquery.TableFieldSchema{
+					&bigquery.TableFieldSchema{Mode: "nullable", Name: "Name", Type: "string"},
+				},
+			},
+		},
+	}
+
+	It("should convert values into BigQuery JSON representations", func() {
+		at := time.Date(2015, 1, 2, 3, 4, 5, 600000000, time.FixedZone("", 3600))
+		row := map[string]interface{}{
+			"small": 42,
+			"Large": int64(1) << 60,
+			"At":    at,
+			"Day":   at,
+			"Blob":  []byte("hi"),
+			"Price": 1.25,
+			"Flags": []interface{}{true, "false"},
+			"Sub":   map[string]interface{}{"Name": "x"},
+		}
+
+		result, err := CoerceRow(schema, row)
+		Expect(err).To(BeNil())
+		Expect(result).To(Equal(map[string]bigquery.JsonValue{
+			"Small": int64(42),
+			"Large": "1152921504606846976",
+			"At":    "2015-01-02T02:04:05.6Z",
+			"Day":   "2015-01-02",
+			"Blob":  "aGk=",
+			"Price": "1.25",
+			"Flags": []bigquery.JsonValue{true, false},
+			"Sub":   map[string]bigquery.JsonValue{"Name": "x"},
+		}))
+	})
+
+	It("should omit null values", func() {
+		result, err := CoerceRow(schema, map[string]interface{}{"Small": 1, "Large": 2, "At": nil})
+		Expect(err).To(BeNil())
+		Expect(result).NotTo(HaveKey("At"))
+	})
+
+	It("should report values that can not be coerced", func() {
+		_, err := CoerceRow(schema, map[string]interface{}{"Small": "one", "Large": uint64(1) << 63})
+		Expect(err).To(Equal(ValidationErrors{
+			&ValidationError{"Small", "can not coerce string to integer"},
+			&ValidationError{"Large", "9223372036854775808 overflows integer"},
+		}))
+	})
+
+	It("should report floats out of the range of integers", func() {
+		for _, f := range []float64{1 << 63, -1 << 64, math.Inf(1), math.Inf(-1)} {
+			_, err := CoerceRow(schema, map[string]interface{}{"Small": f, "Large": 1})
+			Expect(err).To(Equal(ValidationErrors{
+				&ValidationError{"Small", fmt.Sprintf("%g overflows integer", f)},
+			}), "%g", f)
+		}
+		_, err := CoerceRow(schema, map[string]interface{}{"Small": math.NaN(), "Large": 1})
+		Expect(err).NotTo(BeNil())
+		result, err := CoerceRow(schema, map[string]interface{}{"Small": float64(-1 << 63), "Large": 1})
+		Expect(err).To(BeNil())
+		Expect(result["Small"]).To(Equal("-9223372036854775808"))
+	})
+
+	It("should reject nil schemas", func() {
+		_, err := CoerceRow(nil, map[string]interface{}{"Small": 1})
+		Expect(err).To(Equal(ErrNilSchema))
+	})
+})
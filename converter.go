@@ -0,0 +1,117 @@
+package bqschema
+
+import (
+	"reflect"
+	"sync"
+
+	"google.golang.org/api/bigquery/v2"
+)
+
+var defaultConverter = NewConverter()
+
+// Converter converts Go types to BigQuery schemas with a fixed set of options,
+// caching the schema of each type it converts. A Converter is safe for concurrent use;
+// its options never change, use With to derive a Converter with other options.
+//
+// A Converter is not isolated from the process-wide registry: declarations
+// made with RegisterTypeMapping, RegisterConcreteType, RegisterStringType and
+// RegisterJSONOption apply to every Converter, those created before included,
+// and discard their cached schemas. Options declared with the corresponding
+// With functions take precedence over registrations and affect no other
+// Converter.
+type Converter struct {
+	opts *options
+
+	mu         sync.RWMutex
+	generation int // of the type mappings the cache was built with
+	cache      map[reflect.Type][]*bigquery.TableFieldSchema
+}
+
+// NewConverter returns a Converter configured by opts.
+func NewConverter(opts ...Option) *Converter {
+	return &Converter{
+		opts:  newOptions(opts),
+		cache: map[reflect.Type][]*bigquery.TableFieldSchema{},
+	}
+}
+
+// With returns a Converter with the options of c followed by opts, so that
+// opts override them. c is unchanged and keeps its cache; the returned
+// Converter starts with an empty one.
+func (c *Converter) With(opts ...Option) *Converter {
+	o := c.opts.clone()
+	for _, opt := range opts {
+		opt(o)
+	}
+	return &Converter{
+		opts:  o,
+		cache: map[reflect.Type][]*bigquery.TableFieldSchema{},
+	}
+}
+
+// Clone returns a Converter with the options of c and an empty cache.
+func (c *Converter) Clone() *Converter {
+	return c.With()
+}
+
+// ToSchema converts the passed type to a BigQuery table schema.
+func (c *Converter) ToSchema(src interface{}) (*bigquery.TableSchema, error) {
+	t := reflect.TypeOf(src)
+	generation := registryGeneration()
+
+	c.mu.RLock()
+	fields, ok := c.cache[t]
+	stale := c.generation != generation
+	c.mu.RUnlock()
+	if ok && !stale {
+		return &bigquery.TableSchema{Fields: copyFields(fields)}, nil
+	}
+
+	schema, err := convert(src, c.opts)
+	if err != nil {
+		return schema, err
+	}
+
+	c.mu.Lock()
+	if c.generation != generation {
+		c.cache = map[reflect.Type][]*bigquery.TableFieldSchema{}
+		c.generation = generation
+	}
+	c.cache[t] = copyFields(schema.Fields)
+	c.mu.Unlock()
+	return schema, nil
+}
+
+// ToSchemaWithReport converts the passed type like ToSchema, also reporting the
+// decisions made converting it. The type is converted afresh for the report.
+func (c *Converter) ToSchemaWithReport(src interface{}) (*bigquery.TableSchema, *Report, error) {
+	o := *c.opts
+	o.report = &Report{}
+	schema, err := convert(src, &o)
+	return schema, o.report, err
+}
+
+// MustToSchema panics if conversion to a schema encounters an error.
+func (c *Converter) MustToSchema(src interface{}) *bigquery.TableSchema {
+	schema, err := c.ToSchema(src)
+	if err != nil {
+		panic(err)
+	}
+	return schema
+}
+
+func convert(src interface{}, o *options) (*bigquery.TableSchema, error) {
+	if err := o.checkMode(); err != nil {
+		return &bigquery.TableSchema{}, err
+	}
+	schema, err := toSchema(src, o)
+	if err != nil {
+		return schema, err
+	}
+	if o.flatten {
+		if schema.Fields, err = flattenFields(schema.Fields, 0, o); err != nil {
+			return nil, err
+		}
+	}
+	return schema, nil
+}
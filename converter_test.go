@@ -0,0 +1,87 @@
+package bqschema
+
+import (
+	"reflect"
+	"sync"
+
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+
+	"google.golang.org/api/bigquery/v2"
+)
+
+type converterID string
+
+var _ = Describe("Converter", func() {
+	type row struct {
+		ID   converterID
+		Name string
+	}
+
+	It("should convert with its options", func() {
+		c := NewConverter(WithDefaultMode(Nullable))
+		schema, err := c.ToSchema(row{})
+		Expect(err).To(BeNil())
+		Expect(schema).To(Equal(MustToSchema(row{}, WithDefaultMode(Nullable))))
+		Expect(c.MustToSchema(row{}).Fields[0].Mode).To(Equal("nullable"))
+		Expect(func() { c.MustToSchema(1) }).To(Panic())
+	})
+
+	It("should return schemas that do not share cached fields", func() {
+		c := NewConverter()
+		c.MustToSchema(row{}).Fields[0].Name = "changed"
+		Expect(c.MustToSchema(row{}).Fields[0].Name).To(Equal("ID"))
+	})
+
+	It("should see type mappings registered after converting", func() {
+		c := NewConverter()
+		Expect(c.MustToSchema(row{}).Fields[0].Type).To(Equal("string"))
+
+		RegisterTypeMapping(reflect.TypeOf(converterID("")), &bigquery.TableFieldSchema{Type: "bytes"})
+		defer func() {
+			registryMu.Lock()
+			delete(typeMappings, reflect.TypeOf(converterID("")))
+			registryGen++
+			registryMu.Unlock()
+		}()
+		Expect(c.MustToSchema(row{}).Fields[0].Type).To(Equal("bytes"))
+	})
+
+	It("should be safe for concurrent use", func() {
+		c := NewConverter()
+		var wg sync.WaitGroup
+		for i := 0; i < 10; i++ {
+			wg.Add(1)
+			go func() {
+				defer wg.Done()
+				defer GinkgoRecover()
+				Expect(c.MustToSchema(row{}).Fields).To(HaveLen(2))
+			}()
+		}
+		wg.Wait()
+	})
+
+	It("should derive converters without changing the original", func() {
+		base := NewConverter(WithTypeMapping(reflect.TypeOf(converterID("")), &bigquery.TableFieldSchema{Type: "bytes"}))
+		nullable := base.With(WithDefaultMode(Nullable), WithTypeMapping(reflect.TypeOf(converterID("")), &bigquery.TableFieldSchema{Type: "integer"}))
+
+		Expect(base.MustToSchema(row{}).Fields[0]).To(Equal(&bigquery.TableFieldSchema{Mode: "required", Name: "ID", Type: "bytes"}))
+		Expect(nullable.MustToSchema(row{}).Fields[0]).To(Equal(&bigquery.TableFieldSchema{Mode: "nullable", Name: "ID", Type: "integer"}))
+		Expect(base.Clone().MustToSchema(row{})).To(Equal(base.MustToSchema(row{})))
+	})
+
+	It("should derive converters concurrently", func() {
+		base := NewConverter()
+		var wg sync.WaitGroup
+		for i := 0; i < 10; i++ {
+			wg.Add(1)
+			go func(i int) {
+				defer wg.Done()
+				defer GinkgoRecover()
+				c := base.With(WithConcreteType(string(rune('a'+i)), row{}))
+				Expect(c.MustToSchema(row{}).Fields).To(HaveLen(2))
+			}(i)
+		}
+		wg.Wait()
+	})
+})
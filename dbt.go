@@ -0,0 +1,52 @@
+package bqschema
+
+import (
+	"google.golang.org/api/bigquery/v2"
+	"gopkg.in/yaml.v2"
+)
+
+type dbtSchema struct {
+	Version int         `yaml:"version"`
+	Models  []*dbtModel `yaml:"models"`
+}
+
+type dbtModel struct {
+	Name    string       `yaml:"name"`
+	Columns []*dbtColumn `yaml:"columns"`
+}
+
+type dbtColumn struct {
+	Name        string `yaml:"name"`
+	DataType    string `yaml:"data_type"`
+	Description string `yaml:"description,omitempty"`
+}
+
+// ToDBTModelYAML converts the schema of src converted with opts to a dbt
+// schema.yml declaring the model modelName, with a column for each field: its
+// name, BigQuery data type and description. The fields of records are declared
+// as dotted columns below the record, as dbt expects for BigQuery.
+func ToDBTModelYAML(src interface{}, modelName string, opts ...Option) ([]byte, error) {
+	schema, err := ToSchema(src, opts...)
+	if err != nil {
+		return nil, err
+	}
+	model := &dbtModel{Name: modelName}
+	model.Columns = dbtColumns(model.Columns, schema.Fields, "")
+	return yaml.Marshal(&dbtSchema{Version: 2, Models: []*dbtModel{model}})
+}
+
+func dbtColumns(columns []*dbtColumn, fields []*bigquery.TableFieldSchema, prefix string) []*dbtColumn {
+	for _, field := range fields {
+		columns = append(columns, &dbtColumn{
+			Name:        prefix + field.Name,
+			DataType:    dbtType(field),
+			Description: field.Description,
+		})
+		columns = dbtColumns(columns, field.Fields, prefix+field.Name+".")
+	}
+	return columns
+}
+
+func dbtType(field *bigquery.TableFieldSchema) string {
+	return sqlType(field, dbtType)
+}
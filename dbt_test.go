@@ -0,0 +1,50 @@
+package bqschema
+
+import (
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+)
+
+var _ = Describe("ToDBTModelYAML", func() {
+	type address struct {
+		City string `json:"city" description:"City of the address."`
+	}
+	type user struct {
+		ID      int64    `json:"id" description:"Primary key."`
+		Tags    []string `json:"tags"`
+		Address address  `json:"address" description:"Billing address."`
+	}
+
+	It("should declare a column for each field", func() {
+		doc, err := ToDBTModelYAML(user{}, "users")
+		Expect(err).To(BeNil())
+		Expect(string(doc)).To(Equal(`version: 2
+models:
+- name: users
+  columns:
+  - name: id
+    data_type: INT64
+    description: Primary key.
+  - name: tags
+    data_type: ARRAY<STRING>
+  - name: address
+    data_type: STRUCT<` + "`city`" + ` STRING>
+    description: Billing address.
+  - name: address.city
+    data_type: STRING
+    description: City of the address.
+`))
+	})
+
+	It("should convert the type with options", func() {
+		doc, err := ToDBTModelYAML(user{}, "users", WithFlatten(0, "_"))
+		Expect(err).To(BeNil())
+		Expect(string(doc)).To(ContainSubstring("  - name: address_city\n    data_type: STRING\n"))
+		Expect(string(doc)).NotTo(ContainSubstring("STRUCT"))
+	})
+
+	It("should not convert invalid types", func() {
+		_, err := ToDBTModelYAML(1, "numbers")
+		Expect(err).To(Equal(ErrNotStruct))
+	})
+})
@@ -0,0 +1,229 @@
+package bqschema
+
+import (
+	"errors"
+	"fmt"
+	"reflect"
+	"strings"
+	"unicode"
+
+	"google.golang.org/api/bigquery/v2"
+)
+
+// Dialect is a SQL dialect ToSQL writes table definitions in.
+type Dialect string
+
+const (
+	PostgreSQL Dialect = "postgresql"
+	Spanner    Dialect = "spanner" // Cloud Spanner, GoogleSQL dialect
+)
+
+// ToSQL converts the schema of src converted with opts to a CREATE TABLE
+// statement in dialect, for mirroring a BigQuery table into an operational
+// database from the same struct. The table is named after the type in snake case and its columns are
+// those of ToSchema: required columns are NOT NULL, repeated columns arrays and
+// records JSON documents. Fields tagged `bqschema:"key"` form the primary key,
+// which Spanner tables require.
+//
+// Column types are mapped to their nearest equivalent; types the dialect has
+// no equivalent for, such as GEOGRAPHY or, in Spanner, DATETIME, fail with a
+// FieldError of kind KindInconvertible. Spanner names are letters, digits and
+// underscores starting with a letter; tables and columns named otherwise fail
+// to convert.
+func ToSQL(src interface{}, dialect Dialect, opts ...Option) (string, error) {
+	schema, err := ToSchema(src, opts...)
+	if err != nil {
+		return "", err
+	}
+	t := reflect.TypeOf(src)
+	if t.Name() == "" {
+		return "", errors.New("anonymous struct types have no table name")
+	}
+
+	var quote func(string) string
+	var columnType func(*bigquery.TableFieldSchema) (string, bool)
+	validName := func(string) bool { return true }
+	switch dialect {
+	case PostgreSQL:
+		quote = func(name string) string { return `"` + strings.Replace(name, `"`, `""`, -1) + `"` }
+		columnType = postgresType
+	case Spanner:
+		quote = func(name string) string { return "`" + name + "`" }
+		columnType = spannerType
+		validName = isSpannerName
+	default:
+		return "", fmt.Errorf("unknown SQL dialect %q", dialect)
+	}
+
+	var keys []string
+	for _, fp := range newOptions(opts).plan(t).fields {
+		if !fp.tag.skip && hasOption(fp.sf.Tag.Get("bqschema"), "key") {
+			keys = append(keys, quote(fp.tag.name))
+		}
+	}
+	if dialect == Spanner && len(keys) == 0 {
+		return "", errors.New("Spanner tables require a primary key; tag its fields `bqschema:\"key\"`")
+	}
+
+	table := snakeCase(t.Name())
+	if !validName(table) {
+		return "", fmt.Errorf("table name %q is not a valid %s name", table, dialect)
+	}
+
+	var b strings.Builder
+	fmt.Fprintf(&b, "CREATE TABLE %s (\n", quote(table))
+	for i, field := range schema.Fields {
+		if !validName(field.Name) {
+			return "", &FieldError{Path: field.Name, Kind: KindTag, Wrapped: fmt.Errorf("not a valid %s name", dialect)}
+		}
+		ct, ok := columnType(field)
+		if !ok {
+			return "", &FieldError{Path: field.Name, Kind: KindInconvertible, Wrapped: fmt.Errorf("%s has no %s column type", strings.ToUpper(canonicalType(field.Type)), dialect)}
+		}
+		fmt.Fprintf(&b, "  %s %s", quote(field.Name), ct)
+		if canonicalMode(field.Mode) == "required" {
+			b.WriteString(" NOT NULL")
+		}
+		if i < len(schema.Fields)-1 || (dialect == PostgreSQL && len(keys) > 0) {
+			b.WriteString(",")
+		}
+		b.WriteString("\n")
+	}
+	switch {
+	case dialect == PostgreSQL && len(keys) > 0:
+		fmt.Fprintf(&b, "  PRIMARY KEY (%s)\n);\n", strings.Join(keys, ", "))
+	case dialect == Spanner:
+		fmt.Fprintf(&b, ") PRIMARY KEY (%s);\n", strings.Join(keys, ", "))
+	default:
+		b.WriteString(");\n")
+	}
+	return b.String(), nil
+}
+
+func postgresType(field *bigquery.TableFieldSchema) (string, bool) {
+	var t string
+	switch canonicalType(field.Type) {
+	case "record":
+		return "JSONB", true
+	case "string":
+		t = "TEXT"
+		if field.MaxLength > 0 {
+			t = fmt.Sprintf("VARCHAR(%d)", field.MaxLength)
+		}
+	case "bytes":
+		t = "BYTEA"
+	case "integer":
+		t = "BIGINT"
+	case "float":
+		t = "DOUBLE PRECISION"
+	case "boolean":
+		t = "BOOLEAN"
+	case "numeric", "bignumeric":
+		t = "NUMERIC"
+		if field.Precision > 0 {
+			t = fmt.Sprintf("NUMERIC(%d, %d)", field.Precision, field.Scale)
+		}
+	case "timestamp":
+		t = "TIMESTAMPTZ"
+	case "datetime":
+		t = "TIMESTAMP"
+	case "date":
+		t = "DATE"
+	case "time":
+		t = "TIME"
+	case "interval":
+		t = "INTERVAL"
+	case "json":
+		t = "JSONB"
+	case "range":
+		if field.RangeElementType == nil {
+			return "", false
+		}
+		switch canonicalType(field.RangeElementType.Type) {
+		case "date":
+			t = "DATERANGE"
+		case "datetime":
+			t = "TSRANGE"
+		case "timestamp":
+			t = "TSTZRANGE"
+		default:
+			return "", false
+		}
+	default:
+		return "", false
+	}
+	if isRepeated(field) {
+		t += "[]"
+	}
+	return t, true
+}
+
+func spannerType(field *bigquery.TableFieldSchema) (string, bool) {
+	var t string
+	switch canonicalType(field.Type) {
+	case "record", "json":
+		return "JSON", true
+	case "string":
+		t = "STRING(MAX)"
+		if field.MaxLength > 0 {
+			t = fmt.Sprintf("STRING(%d)", field.MaxLength)
+		}
+	case "bytes":
+		t = "BYTES(MAX)"
+		if field.MaxLength > 0 {
+			t = fmt.Sprintf("BYTES(%d)", field.MaxLength)
+		}
+	case "integer":
+		t = "INT64"
+	case "float":
+		t = "FLOAT64"
+	case "boolean":
+		t = "BOOL"
+	case "numeric":
+		t = "NUMERIC"
+	case "timestamp":
+		t = "TIMESTAMP"
+	case "date":
+		t = "DATE"
+	default:
+		return "", false
+	}
+	if isRepeated(field) {
+		t = "ARRAY<" + t + ">"
+	}
+	return t, true
+}
+
+// isSpannerName reports whether s is a Spanner table or column name: a letter
+// followed by letters, digits or underscores, at most 128 characters.
+func isSpannerName(s string) bool {
+	if s == "" || len(s) > 128 {
+		return false
+	}
+	for i, r := range s {
+		switch {
+		case 'a' <= r && r <= 'z', 'A' <= r && r <= 'Z':
+		case i > 0 && (r == '_' || '0' <= r && r <= '9'):
+		default:
+			return false
+		}
+	}
+	return true
+}
+
+// snakeCase converts a Go identifier to snake case: UserEvent is user_event
+// and HTTPRequest http_request.
+func snakeCase(name string) string {
+	runes := []rune(name)
+	var b strings.Builder
+	for i, r := range runes {
+		if unicode.IsUpper(r) {
+			if i > 0 && (unicode.IsLower(runes[i-1]) || i+1 < len(runes) && unicode.IsLower(runes[i+1]) && unicode.IsUpper(runes[i-1])) {
+				b.WriteByte('_')
+			}
+			r = unicode.ToLower(r)
+		}
+		b.WriteRune(r)
+	}
+	return b.String()
+}
@@ -0,0 +1,125 @@
+package bqschema
+
+import (
+	"errors"
+	"time"
+
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+)
+
+type ddlAddress struct {
+	City string `json:"city"`
+}
+
+type UserEvent struct {
+	ID      int64      `json:"id" bqschema:"key"`
+	At      time.Time  `json:"at" bqschema:"key"`
+	Name    string     `json:"name,omitempty"`
+	Score   float64    `json:"score"`
+	Tags    []string   `json:"tags"`
+	Raw     []byte     `json:"raw,omitempty" bigquery:",type=BYTES"`
+	Price   string     `json:"price,omitempty" bigquery:",type=NUMERIC"`
+	Day     string     `json:"day,omitempty" bigquery:",type=DATE"`
+	Address ddlAddress `json:"address"`
+}
+
+type ddlNoKey struct {
+	ID int64 `json:"id"`
+}
+
+type ddlPlace struct {
+	ID       int64  `json:"id" bqschema:"key"`
+	Location string `json:"location" bigquery:",type=GEOGRAPHY"`
+}
+
+type ddlVisit struct {
+	ID   int64  `json:"id" bqschema:"key"`
+	Stay string `json:"stay" bigquery:",type=DATETIME"`
+}
+
+var _ = Describe("ToSQL", func() {
+	It("should write PostgreSQL tables", func() {
+		sql, err := ToSQL(UserEvent{}, PostgreSQL)
+		Expect(err).To(BeNil())
+		Expect(sql).To(Equal(`CREATE TABLE "user_event" (
+  "id" BIGINT NOT NULL,
+  "at" TIMESTAMPTZ,
+  "name" TEXT,
+  "score" DOUBLE PRECISION NOT NULL,
+  "tags" TEXT[],
+  "raw" BYTEA,
+  "price" NUMERIC,
+  "day" DATE,
+  "address" JSONB,
+  PRIMARY KEY ("id", "at")
+);
+`))
+
+		sql, err = ToSQL(ddlNoKey{}, PostgreSQL)
+		Expect(err).To(BeNil())
+		Expect(sql).To(Equal("CREATE TABLE \"ddl_no_key\" (\n  \"id\" BIGINT NOT NULL\n);\n"))
+	})
+
+	It("should write Spanner tables", func() {
+		sql, err := ToSQL(UserEvent{}, Spanner)
+		Expect(err).To(BeNil())
+		Expect(sql).To(Equal("CREATE TABLE `user_event` (\n" +
+			"  `id` INT64 NOT NULL,\n" +
+			"  `at` TIMESTAMP,\n" +
+			"  `name` STRING(MAX),\n" +
+			"  `score` FLOAT64 NOT NULL,\n" +
+			"  `tags` ARRAY<STRING(MAX)>,\n" +
+			"  `raw` BYTES(MAX),\n" +
+			"  `price` NUMERIC,\n" +
+			"  `day` DATE,\n" +
+			"  `address` JSON\n" +
+			") PRIMARY KEY (`id`, `at`);\n"))
+
+		_, err = ToSQL(ddlNoKey{}, Spanner)
+		Expect(err).To(MatchError("Spanner tables require a primary key; tag its fields `bqschema:\"key\"`"))
+	})
+
+	It("should reject column types without equivalent", func() {
+		_, err := ToSQL(ddlPlace{}, PostgreSQL)
+		Expect(err).To(MatchError("location: GEOGRAPHY has no postgresql column type"))
+		Expect(errors.Is(err, &FieldError{Kind: KindInconvertible})).To(BeTrue())
+		_, err = ToSQL(ddlVisit{}, Spanner)
+		Expect(err).To(MatchError("stay: DATETIME has no spanner column type"))
+		_, err = ToSQL(ddlVisit{}, Dialect("mysql"))
+		Expect(err).To(MatchError(`unknown SQL dialect "mysql"`))
+		_, err = ToSQL(struct{ A int }{}, PostgreSQL)
+		Expect(err).To(MatchError("anonymous struct types have no table name"))
+	})
+
+	It("should reject names Spanner does not allow", func() {
+		type Named struct {
+			ID   int64  `json:"id" bqschema:"key"`
+			Note string "json:\"note`s\""
+		}
+		_, err := ToSQL(Named{}, Spanner)
+		Expect(err).To(MatchError("note`s: not a valid spanner name"))
+		Expect(errors.Is(err, &FieldError{Path: "note`s", Kind: KindTag})).To(BeTrue())
+		_, err = ToSQL(Named{}, PostgreSQL)
+		Expect(err).To(BeNil())
+
+		type Größe struct {
+			ID int64 `json:"id" bqschema:"key"`
+		}
+		_, err = ToSQL(Größe{}, Spanner)
+		Expect(err).To(MatchError(`table name "größe" is not a valid spanner name`))
+	})
+
+	It("should convert with options", func() {
+		sql, err := ToSQL(ddlNoKey{}, PostgreSQL, WithDefaultMode(Nullable))
+		Expect(err).To(BeNil())
+		Expect(sql).To(Equal("CREATE TABLE \"ddl_no_key\" (\n  \"id\" BIGINT\n);\n"))
+	})
+
+	It("should name tables in snake case", func() {
+		Expect(snakeCase("UserEvent")).To(Equal("user_event"))
+		Expect(snakeCase("HTTPRequest")).To(Equal("http_request"))
+		Expect(snakeCase("userID")).To(Equal("user_id"))
+		Expect(snakeCase("row")).To(Equal("row"))
+	})
+})
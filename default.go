@@ -0,0 +1,78 @@
+package bqschema
+
+import (
+	"regexp"
+	"strings"
+)
+
+var (
+	numberLiteral  = regexp.MustCompile(`^[+-]?(\d+\.?\d*|\.\d+)([eE][+-]?\d+)?$`)
+	integerLiteral = regexp.MustCompile(`^[+-]?\d+$`)
+	stringLiteral  = regexp.MustCompile(`^(?s)('.*'|".*")$`)
+	typedLiteral   = regexp.MustCompile(`^(?is)(date|datetime|time|timestamp|json|numeric|bignumeric|range<\w+>)\s+('.*'|".*")$`)
+	bytesLiteral   = regexp.MustCompile(`^(?is)b('.*'|".*")$`)
+
+	defaultFunctions = map[string]string{
+		"CURRENT_DATE()":      "date",
+		"CURRENT_DATETIME()":  "datetime",
+		"CURRENT_TIME()":      "time",
+		"CURRENT_TIMESTAMP()": "timestamp",
+		"GENERATE_UUID()":     "string",
+		"SESSION_USER()":      "string",
+	}
+)
+
+// checkDefault checks that the default value expression of a column roughly
+// matches its type. Expressions whose type is not recognized are accepted.
+func checkDefault(expr, bqType, mode string) error {
+	colType := canonicalType(bqType)
+	if mode == "repeated" || colType == "record" {
+		return nil
+	}
+
+	exprType := defaultType(expr)
+	switch {
+	case exprType == "":
+		return nil
+	case exprType == "null":
+		if mode == "required" {
+			return fieldError(KindTag, "default NULL not allowed for required column")
+		}
+		return nil
+	case exprType == colType:
+		return nil
+	case exprType == "integer" && (colType == "float" || colType == "numeric" || colType == "bignumeric"):
+		return nil
+	case exprType == "float" && (colType == "numeric" || colType == "bignumeric"):
+		return nil
+	}
+	return fieldError(KindTag, "default %s not allowed for %s column", expr, colType)
+}
+
+// defaultType returns the type of a default value expression, "null" for NULL
+// and the empty string if it is not recognized.
+func defaultType(expr string) string {
+	expr = strings.TrimSpace(expr)
+	switch upper := strings.ToUpper(expr); {
+	case upper == "NULL":
+		return "null"
+	case upper == "TRUE" || upper == "FALSE":
+		return "boolean"
+	case integerLiteral.MatchString(expr):
+		return "integer"
+	case numberLiteral.MatchString(expr):
+		return "float"
+	case stringLiteral.MatchString(expr):
+		return "string"
+	case bytesLiteral.MatchString(expr):
+		return "bytes"
+	case typedLiteral.MatchString(expr):
+		t := strings.ToLower(typedLiteral.FindStringSubmatch(expr)[1])
+		if strings.HasPrefix(t, "range") {
+			return "range"
+		}
+		return t
+	default:
+		return defaultFunctions[strings.Join(strings.Fields(upper), "")]
+	}
+}
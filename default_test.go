@@ -0,0 +1,65 @@
+package bqschema
+
+import (
+	"time"
+
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+
+	"google.golang.org/api/bigquery/v2"
+)
+
+var _ = Describe("Default values", func() {
+	It("should set default value expressions", func() {
+		schema, err := ToSchema(struct {
+			Created time.Time `default:"CURRENT_TIMESTAMP()"`
+			Status  string    `default:"'unknown'"`
+			Count   float64   `default:"0"`
+			Price   string    `bigquery:",type=numeric" default:"1.5"`
+			Day     time.Time `bigquery:",type=date" default:"DATE '2020-01-01'"`
+			Active  bool      `default:"TRUE"`
+			Note    *string   `json:",omitempty" default:"NULL"`
+			ID      string    `default:"concat('u-', GENERATE_UUID())"`
+		}{})
+		Expect(err).To(BeNil())
+		defaults := make([]string, len(schema.Fields))
+		for i, f := range schema.Fields {
+			defaults[i] = f.DefaultValueExpression
+		}
+		Expect(defaults).To(Equal([]string{
+			"CURRENT_TIMESTAMP()", "'unknown'", "0", "1.5", "DATE '2020-01-01'", "TRUE", "NULL", "concat('u-', GENERATE_UUID())",
+		}))
+	})
+
+	It("should reject defaults not matching the column type", func() {
+		table := [][]interface{}{
+			[]interface{}{struct {
+				A int `default:"'x'"`
+			}{}, "A: default 'x' not allowed for integer column"},
+			[]interface{}{struct {
+				A int `default:"1.5"`
+			}{}, "A: default 1.5 not allowed for integer column"},
+			[]interface{}{struct {
+				A string `default:"current_timestamp ( )"`
+			}{}, "A: default current_timestamp ( ) not allowed for string column"},
+			[]interface{}{struct {
+				A string `default:"NULL"`
+			}{}, "A: default NULL not allowed for required column"},
+		}
+		for _, data := range table {
+			_, err := ToSchema(data[0])
+			Expect(err).To(MatchError(data[1]))
+		}
+	})
+
+	It("should add columns with their default", func() {
+		p, err := PlanMigration("p.d.t", struct {
+			ID     int    `json:"id"`
+			Status string `json:"status,omitempty" default:"'new'"`
+		}{}, &bigquery.TableSchema{Fields: []*bigquery.TableFieldSchema{
+			&bigquery.TableFieldSchema{Mode: "REQUIRED", Name: "id", Type: "INTEGER"},
+		}})
+		Expect(err).To(BeNil())
+		Expect(p.Statements()).To(Equal([]string{"ALTER TABLE `p.d.t` ADD COLUMN `status` STRING DEFAULT 'new'"}))
+	})
+})
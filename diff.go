@@ -0,0 +1,103 @@
+package bqschema
+
+import (
+	"context"
+	"strings"
+
+	"google.golang.org/api/bigquery/v2"
+)
+
+// SchemaDiff lists the fields that differ between two schemas.
+type SchemaDiff struct {
+	Added   []*FieldChange
+	Removed []*FieldChange
+	Changed []*FieldChange
+}
+
+// FieldChange describes a field that differs between two schemas. Old is nil
+// for added fields and New is nil for removed fields.
+type FieldChange struct {
+	Path string
+	Old  *bigquery.TableFieldSchema
+	New  *bigquery.TableFieldSchema
+}
+
+// TypeChanged reports whether the field's type differs.
+func (c *FieldChange) TypeChanged() bool {
+	return c.Old != nil && c.New != nil && canonicalType(c.Old.Type) != canonicalType(c.New.Type)
+}
+
+// ModeChanged reports whether the field's mode differs.
+func (c *FieldChange) ModeChanged() bool {
+	return c.Old != nil && c.New != nil && canonicalMode(c.Old.Mode) != canonicalMode(c.New.Mode)
+}
+
+// DescriptionChanged reports whether the field's description differs.
+func (c *FieldChange) DescriptionChanged() bool {
+	return c.Old != nil && c.New != nil && c.Old.Description != c.New.Description
+}
+
+// Empty reports whether the schemas are equivalent.
+func (d *SchemaDiff) Empty() bool {
+	return len(d.Added) == 0 && len(d.Removed) == 0 && len(d.Changed) == 0
+}
+
+// Diff compares two schemas. Fields are matched by name ignoring case, and fields
+// of records present in both schemas are compared recursively.
+func Diff(old, new *bigquery.TableSchema) *SchemaDiff {
+	d := &SchemaDiff{}
+	d.diffFields(old.Fields, new.Fields, "")
+	return d
+}
+
+func (d *SchemaDiff) diffFields(old, new []*bigquery.TableFieldSchema, prefix string) {
+	oldByName := make(map[string]*bigquery.TableFieldSchema, len(old))
+	for _, field := range old {
+		oldByName[strings.ToLower(field.Name)] = field
+	}
+	newByName := make(map[string]*bigquery.TableFieldSchema, len(new))
+	for _, field := range new {
+		newByName[strings.ToLower(field.Name)] = field
+	}
+
+	for _, field := range old {
+		if _, ok := newByName[strings.ToLower(field.Name)]; !ok {
+			d.Removed = append(d.Removed, &FieldChange{Path: prefix + field.Name, Old: field})
+		}
+	}
+	for _, field := range new {
+		path := prefix + field.Name
+		oldField, ok := oldByName[strings.ToLower(field.Name)]
+		if !ok {
+			d.Added = append(d.Added, &FieldChange{Path: path, New: field})
+			continue
+		}
+
+		change := &FieldChange{Path: path, Old: oldField, New: field}
+		if change.TypeChanged() || change.ModeChanged() || change.DescriptionChanged() {
+			d.Changed = append(d.Changed, change)
+		}
+		if !change.TypeChanged() && canonicalType(field.Type) == "record" {
+			d.diffFields(oldField.Fields, field.Fields, path+".")
+		}
+	}
+}
+
+// CompareWithTable diffs the live schema of a table against the schema of src
+// converted with opts, letting services check at startup that they are not
+// writing to a drifted table. Fields only in src are reported as added.
+func CompareWithTable(ctx context.Context, svc *bigquery.Service, project, dataset, table string, src interface{}, opts ...Option) (*SchemaDiff, error) {
+	schema, err := ToSchema(src, opts...)
+	if err != nil {
+		return nil, err
+	}
+	t, err := svc.Tables.Get(project, dataset, table).Context(ctx).Do()
+	if err != nil {
+		return nil, err
+	}
+	live := t.Schema
+	if live == nil {
+		live = &bigquery.TableSchema{}
+	}
+	return Diff(live, schema), nil
+}
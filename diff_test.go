@@ -0,0 +1,102 @@
+package bqschema
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+
+	"google.golang.org/api/bigquery/v2"
+	"google.golang.org/api/option"
+)
+
+var _ = Describe("Diff", func() {
+	old := &bigquery.TableSchema{
+		Fields: []*bigquery.TableFieldSchema{
+			&bigquery.TableFieldSchema{Mode: "REQUIRED", Name: "id", Type: "INTEGER"},
+			&bigquery.TableFieldSchema{Mode: "NULLABLE", Name: "gone", Type: "STRING"},
+			&bigquery.TableFieldSchema{Mode: "REQUIRED", Name: "score", Type: "INTEGER"},
+			&bigquery.TableFieldSchema{
+				Mode: "NULLABLE",
+				Name: "address",
+				Type: "RECORD",
+				Fields: []*bigquery.TableFieldSchema{
+					&bigquery.TableFieldSchema{Mode: "NULLABLE", Name: "city", Type: "STRING"},
+				},
+			},
+		},
+	}
+	new := &bigquery.TableSchema{
+		Fields: []*bigquery.TableFieldSchema{
+			&bigquery.TableFieldSchema{Mode: "required", Name: "ID", Type: "int64"},
+			&bigquery.TableFieldSchema{Mode: "nullable", Name: "score", Type: "float"},
+			&bigquery.TableFieldSchema{
+				Mode: "nullable",
+				Name: "address",
+				Type: "record",
+				Fields: []*bigquery.TableFieldSchema{
+					&bigquery.TableFieldSchema{Mode: "nullable", Name: "city", Type: "string", Description: "City name."},
+					&bigquery.TableFieldSchema{Mode: "nullable", Name: "zip", Type: "string"},
+				},
+			},
+		},
+	}
+
+	It("should report added, removed and changed fields", func() {
+		d := Diff(old, new)
+		Expect(d.Empty()).To(BeFalse())
+		Expect(d.Added).To(Equal([]*FieldChange{&FieldChange{Path: "address.zip", New: new.Fields[2].Fields[1]}}))
+		Expect(d.Removed).To(Equal([]*FieldChange{&FieldChange{Path: "gone", Old: old.Fields[1]}}))
+		Expect(d.Changed).To(HaveLen(2))
+
+		Expect(d.Changed[0].Path).To(Equal("score"))
+		Expect(d.Changed[0].TypeChanged()).To(BeTrue())
+		Expect(d.Changed[0].ModeChanged()).To(BeTrue())
+		Expect(d.Changed[1].Path).To(Equal("address.city"))
+		Expect(d.Changed[1].TypeChanged()).To(BeFalse())
+		Expect(d.Changed[1].DescriptionChanged()).To(BeTrue())
+	})
+
+	It("should find no differences between equivalent schemas", func() {
+		Expect(Diff(old, old).Empty()).To(BeTrue())
+	})
+})
+
+var _ = Describe("CompareWithTable", func() {
+	type row struct {
+		ID   int    `json:"id"`
+		Name string `json:"name"`
+	}
+
+	It("should diff the live table schema against the struct", func() {
+		server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			defer GinkgoRecover()
+			Expect(r.URL.Path).To(Equal("/projects/p/datasets/d/tables/t"))
+			json.NewEncoder(w).Encode(&bigquery.Table{
+				Schema: &bigquery.TableSchema{
+					Fields: []*bigquery.TableFieldSchema{
+						&bigquery.TableFieldSchema{Mode: "REQUIRED", Name: "id", Type: "INTEGER"},
+					},
+				},
+			})
+		}))
+		defer server.Close()
+		svc, err := bigquery.NewService(context.Background(), option.WithEndpoint(server.URL), option.WithoutAuthentication())
+		Expect(err).To(BeNil())
+
+		d, err := CompareWithTable(context.Background(), svc, "p", "d", "t", row{})
+		Expect(err).To(BeNil())
+		Expect(d.Removed).To(BeEmpty())
+		Expect(d.Changed).To(BeEmpty())
+		Expect(d.Added).To(HaveLen(1))
+		Expect(d.Added[0].Path).To(Equal("name"))
+
+		d, err = CompareWithTable(context.Background(), svc, "p", "d", "t", row{}, WithDefaultMode(Nullable))
+		Expect(err).To(BeNil())
+		Expect(d.Changed).To(HaveLen(1))
+		Expect(d.Changed[0].ModeChanged()).To(BeTrue())
+	})
+})
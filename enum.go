@@ -0,0 +1,152 @@
+package bqschema
+
+import (
+	"fmt"
+	"reflect"
+	"strings"
+	"time"
+
+	"google.golang.org/api/bigquery/v2"
+)
+
+// Enum is implemented by types whose values are limited to a fixed set of strings.
+// Fields of such types, and fields tagged `bigquery:",enum=a|b|c"`, convert to
+// string columns whose description lists the allowed values. Enums of kinds
+// other than string are encoded with their String method, which they must
+// have on values, not only on pointers.
+type Enum interface {
+	BigQueryEnum() []string
+}
+
+var enumType = reflect.TypeOf((*Enum)(nil)).Elem()
+
+// enumValues returns the values allowed by the field's enum tag or by its type implementing Enum.
+func enumValues(t reflect.Type, tag fieldTag) ([]string, bool) {
+	if t.Kind() == reflect.Slice || t.Kind() == reflect.Array {
+		return nil, false
+	}
+	if values, ok := tag.attrs["enum"]; ok {
+		return strings.Split(values, "|"), true
+	}
+	if t.Implements(enumType) {
+		return reflect.Zero(t).Interface().(Enum).BigQueryEnum(), true
+	}
+	if reflect.PtrTo(t).Implements(enumType) {
+		return reflect.New(t).Interface().(Enum).BigQueryEnum(), true
+	}
+	return nil, false
+}
+
+// checkEnum verifies that values of the enum type t encode as strings.
+func checkEnum(t reflect.Type) error {
+	if t.Kind() != reflect.String && !t.Implements(stringerType) {
+		return fieldError(KindTag, "enum of %s requires a String method on %s values", t.Kind(), t)
+	}
+	return nil
+}
+
+func enumField(name, mode string, values []string) *bigquery.TableFieldSchema {
+	return &bigquery.TableFieldSchema{
+		Description: fmt.Sprintf("One of: %s.", strings.Join(values, ", ")),
+		Mode:        mode,
+		Name:        name,
+		Type:        "string",
+	}
+}
+
+// NewEnumValidator returns a function checking that the enum columns of rows
+// converted from src's type only hold allowed values. Rows are keyed by column
+// name like those checked by ValidateValue, as named with opts; errors returned
+// are ValidationErrors.
+func NewEnumValidator(src interface{}, opts ...Option) (func(row map[string]interface{}) error, error) {
+	t := reflect.TypeOf(src)
+	if t.Kind() != reflect.Struct {
+		return nil, ErrNotStruct
+	}
+	enums := map[string]map[string]bool{}
+	newOptions(opts).collectEnums(t, nil, enums, map[reflect.Type]bool{})
+
+	return func(row map[string]interface{}) error {
+		var errs ValidationErrors
+		for path, allowed := range enums {
+			walkPath(reflect.ValueOf(row), strings.Split(path, "."), "", func(p string, v reflect.Value) {
+				s := fmt.Sprint(v.Interface())
+				if !allowed[s] {
+					errs = append(errs, &ValidationError{p, fmt.Sprintf("%q is not an allowed value", s)})
+				}
+			})
+		}
+		if len(errs) > 0 {
+			return errs
+		}
+		return nil
+	}, nil
+}
+
+func (o *options) collectEnums(t reflect.Type, prefix []string, enums map[string]map[string]bool, seen map[reflect.Type]bool) {
+	if seen[t] {
+		return
+	}
+	seen[t] = true
+	defer delete(seen, t)
+
+	for _, fp := range o.plan(t).fields {
+		sf, tag := fp.sf, fp.tag
+		if tag.skip {
+			continue
+		}
+		path := append(append([]string{}, prefix...), tag.name)
+
+		ft := pointerGuard(sf.Type).Type()
+		if ft.Kind() == reflect.Slice || ft.Kind() == reflect.Array {
+			ft = pointerGuard(ft.Elem()).Type()
+		}
+		if values, ok := enumValues(ft, tag); ok {
+			allowed := make(map[string]bool, len(values))
+			for _, value := range values {
+				allowed[value] = true
+			}
+			enums[strings.Join(path, ".")] = allowed
+			continue
+		}
+		if ft.Kind() == reflect.Struct && !ft.ConvertibleTo(reflect.TypeOf(time.Time{})) {
+			o.collectEnums(ft, path, enums, seen)
+		}
+	}
+}
+
+// walkPath calls fn with every non-null value found at the dotted path through
+// nested maps, descending into arrays along the way.
+func walkPath(v reflect.Value, parts []string, prefix string, fn func(path string, v reflect.Value)) {
+	v = indirectValue(v)
+	if !v.IsValid() {
+		return
+	}
+	if v.Kind() == reflect.Slice || v.Kind() == reflect.Array {
+		if v.Type().Elem().Kind() == reflect.Uint8 && len(parts) == 0 {
+			fn(prefix, v)
+			return
+		}
+		for i := 0; i < v.Len(); i++ {
+			walkPath(v.Index(i), parts, fmt.Sprintf("%s[%d]", prefix, i), fn)
+		}
+		return
+	}
+	if len(parts) == 0 {
+		fn(prefix, v)
+		return
+	}
+	if v.Kind() != reflect.Map || v.Type().Key().Kind() != reflect.String {
+		return
+	}
+
+	for _, key := range v.MapKeys() {
+		if strings.EqualFold(key.String(), parts[0]) {
+			path := key.String()
+			if prefix != "" {
+				path = prefix + "." + path
+			}
+			walkPath(v.MapIndex(key), parts[1:], path, fn)
+		}
+	}
+}
@@ -0,0 +1,98 @@
+package bqschema
+
+import (
+	"errors"
+	"reflect"
+
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+
+	"google.golang.org/api/bigquery/v2"
+)
+
+type enumColor int
+
+func (c enumColor) BigQueryEnum() []string { return []string{"red", "green"} }
+
+func (c enumColor) String() string { return c.BigQueryEnum()[c] }
+
+type enumSize string
+
+type enumLevel int
+
+func (l enumLevel) BigQueryEnum() []string { return []string{"low", "high"} }
+
+func (s *enumSize) BigQueryEnum() []string { return []string{"S", "M", "L"} }
+
+var _ = Describe("Enums", func() {
+	type item struct {
+		Size enumSize
+	}
+	type row struct {
+		Color  enumColor
+		Colors []enumColor `json:"colors"`
+		Status string      `bigquery:",enum=active|deleted"`
+		Items  []item
+	}
+
+	It("should convert enums to string columns listing the allowed values", func() {
+		schema, err := ToSchema(row{})
+		Expect(err).To(BeNil())
+		Expect(schema.Fields).To(Equal([]*bigquery.TableFieldSchema{
+			&bigquery.TableFieldSchema{Mode: "required", Name: "Color", Type: "string", Description: "One of: red, green."},
+			&bigquery.TableFieldSchema{Mode: "repeated", Name: "colors", Type: "string", Description: "One of: red, green."},
+			&bigquery.TableFieldSchema{Mode: "required", Name: "Status", Type: "string", Description: "One of: active, deleted."},
+			&bigquery.TableFieldSchema{
+				Mode: "repeated",
+				Name: "Items",
+				Type: "record",
+				Fields: []*bigquery.TableFieldSchema{
+					&bigquery.TableFieldSchema{Mode: "required", Name: "Size", Type: "string", Description: "One of: S, M, L."},
+				},
+			},
+		}))
+	})
+
+	It("should coerce enum values to strings", func() {
+		encoded, err := structToRow(reflect.ValueOf(row{Color: 1, Status: "active"}))
+		Expect(err).To(BeNil())
+		values, err := CoerceRow(MustToSchema(row{}), encoded)
+		Expect(err).To(BeNil())
+		Expect(values["Color"]).To(Equal("green"))
+	})
+
+	It("should reject enums of other kinds than string without a String method", func() {
+		_, err := ToSchema(struct{ Level enumLevel }{})
+		Expect(err).To(MatchError("Level: enum of int requires a String method on bqschema.enumLevel values"))
+		Expect(errors.Is(err, &FieldError{Path: "Level", Kind: KindTag})).To(BeTrue())
+		_, err = ToSchema(struct {
+			Codes []int `bigquery:",enum=1|2"`
+		}{})
+		Expect(err).To(MatchError("Codes: enum of int requires a String method on int values"))
+		_, err = ToSchema(struct {
+			Code string `bigquery:",enum=1|2"`
+		}{})
+		Expect(err).To(BeNil())
+	})
+
+	It("should validate rows against the allowed values", func() {
+		validate, err := NewEnumValidator(row{})
+		Expect(err).To(BeNil())
+
+		Expect(validate(map[string]interface{}{
+			"Color":  "red",
+			"colors": []string{"green"},
+			"status": "active",
+			"Items":  []interface{}{map[string]interface{}{"Size": "M"}},
+		})).To(Succeed())
+
+		Expect(validate(map[string]interface{}{
+			"Color":  "red",
+			"Status": "gone",
+			"Items":  []interface{}{map[string]interface{}{"Size": "M"}, map[string]interface{}{"Size": "XL"}},
+		})).To(ConsistOf(
+			&ValidationError{"Status", `"gone" is not an allowed value`},
+			&ValidationError{"Items[1].Size", `"XL" is not an allowed value`},
+		))
+	})
+})
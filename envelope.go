@@ -0,0 +1,61 @@
+package bqschema
+
+import (
+	"errors"
+	"strings"
+
+	"google.golang.org/api/bigquery/v2"
+)
+
+// EnvelopeSchema converts an event envelope and the variants of its payload to
+// the schema of a table holding every kind of event: the columns of envelope
+// followed by a nullable payload record holding the union of the fields of the
+// variants, all nullable, as Merge unions them.
+//
+//	schema, err := EnvelopeSchema(Envelope{}, UserCreated{}, UserDeleted{})
+//
+// The envelope must not declare the payload column itself; tag a field holding
+// the payload `bigquery:"-"`. Use the EnvelopeSchema method of a Converter to
+// convert the envelope and variants with options.
+func EnvelopeSchema(envelope interface{}, variants ...interface{}) (*bigquery.TableSchema, error) {
+	return defaultConverter.EnvelopeSchema(envelope, variants...)
+}
+
+// EnvelopeSchema converts an event envelope and the variants of its payload,
+// converted by c, to the schema of a table holding every kind of event.
+func (c *Converter) EnvelopeSchema(envelope interface{}, variants ...interface{}) (*bigquery.TableSchema, error) {
+	if len(variants) == 0 {
+		return nil, errors.New("no payload variants")
+	}
+	schema, err := c.ToSchema(envelope)
+	if err != nil {
+		return nil, err
+	}
+	for _, field := range schema.Fields {
+		if strings.EqualFold(field.Name, "payload") {
+			return nil, &FieldError{Path: field.Name, Kind: KindDuplicate, Wrapped: errors.New("envelope declares the payload column")}
+		}
+	}
+
+	lists := make([][]*bigquery.TableFieldSchema, len(variants))
+	for i, variant := range variants {
+		s, err := c.ToSchema(variant)
+		if err != nil {
+			return nil, err
+		}
+		lists[i] = s.Fields
+	}
+	fields, err := mergeFields(lists, "payload.")
+	if err != nil {
+		return nil, err
+	}
+	relaxFields(fields)
+
+	schema.Fields = append(schema.Fields, &bigquery.TableFieldSchema{
+		Mode:   "nullable",
+		Name:   "payload",
+		Type:   "record",
+		Fields: fields,
+	})
+	return schema, nil
+}
@@ -0,0 +1,68 @@
+package bqschema
+
+import (
+	"errors"
+	"time"
+
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+
+	"google.golang.org/api/bigquery/v2"
+)
+
+var _ = Describe("EnvelopeSchema", func() {
+	type envelope struct {
+		ID      string      `json:"id"`
+		Kind    string      `json:"kind"`
+		At      time.Time   `json:"at"`
+		Payload interface{} `json:"payload" bigquery:"-"`
+	}
+	type created struct {
+		User  string   `json:"user"`
+		Email string   `json:"email"`
+		Roles []string `json:"roles"`
+	}
+	type deleted struct {
+		User   string `json:"user"`
+		Reason string `json:"reason,omitempty"`
+	}
+
+	It("should add the union of the payload variants as a nullable record", func() {
+		schema, err := EnvelopeSchema(envelope{}, created{}, deleted{})
+		Expect(err).To(BeNil())
+		Expect(schema.Fields).To(Equal([]*bigquery.TableFieldSchema{
+			&bigquery.TableFieldSchema{Mode: "required", Name: "id", Type: "string"},
+			&bigquery.TableFieldSchema{Mode: "required", Name: "kind", Type: "string"},
+			&bigquery.TableFieldSchema{Mode: "nullable", Name: "at", Type: "timestamp"},
+			&bigquery.TableFieldSchema{Mode: "nullable", Name: "payload", Type: "record", Fields: []*bigquery.TableFieldSchema{
+				&bigquery.TableFieldSchema{Mode: "nullable", Name: "user", Type: "string"},
+				&bigquery.TableFieldSchema{Mode: "nullable", Name: "email", Type: "string"},
+				&bigquery.TableFieldSchema{Mode: "repeated", Name: "roles", Type: "string"},
+				&bigquery.TableFieldSchema{Mode: "nullable", Name: "reason", Type: "string"},
+			}},
+		}))
+	})
+
+	It("should convert the envelope and variants with the options of a Converter", func() {
+		schema, err := NewConverter(WithDefaultMode(Nullable)).EnvelopeSchema(envelope{}, deleted{})
+		Expect(err).To(BeNil())
+		Expect(schema.Fields[0]).To(Equal(&bigquery.TableFieldSchema{Mode: "nullable", Name: "id", Type: "string"}))
+		Expect(schema.Fields[3].Fields).To(HaveLen(2))
+	})
+
+	It("should reject conflicting variants", func() {
+		_, err := EnvelopeSchema(envelope{}, created{}, struct {
+			User int `json:"user"`
+		}{})
+		Expect(err).To(MatchError("payload.user: conflicting types string and integer"))
+	})
+
+	It("should reject envelopes declaring the payload column", func() {
+		_, err := EnvelopeSchema(struct{ Payload string }{}, created{})
+		Expect(errors.Is(err, &FieldError{Path: "Payload", Kind: KindDuplicate})).To(BeTrue())
+		_, err = EnvelopeSchema(envelope{})
+		Expect(err).To(MatchError("no payload variants"))
+		_, err = EnvelopeSchema(envelope{}, 1)
+		Expect(err).To(Equal(ErrNotStruct))
+	})
+})
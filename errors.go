@@ -0,0 +1,101 @@
+package bqschema
+
+import (
+	"errors"
+	"fmt"
+	"reflect"
+)
+
+// ErrCycle is wrapped by the errors of fields whose type contains itself.
+var ErrCycle = errors.New("recursive type")
+
+// ErrorKind classifies the failure reported by a FieldError.
+type ErrorKind string
+
+const (
+	KindArrayOfArray  ErrorKind = "array of arrays"    // slice of slices, wrapping ErrArrayOfArray
+	KindInconvertible ErrorKind = "inconvertible type" // wrapping an *ErrInconvertibleType
+	KindCycle         ErrorKind = "cycle"              // recursive type, wrapping ErrCycle
+	KindTag           ErrorKind = "invalid tag"        // struct tag not applicable to the field
+	KindStrict        ErrorKind = "strict"             // field rejected by WithStrict
+	KindLossy         ErrorKind = "lossy"              // lossy mapping rejected by WithStrict
+	KindDuplicate     ErrorKind = "duplicate column"   // column name used twice
+	KindLimit         ErrorKind = "limit"              // schema exceeding WithMaxDepth or WithMaxFields
+	KindValidation    ErrorKind = "validation"         // value not conforming to its field, see ValidationError
+	KindDecode        ErrorKind = "decode"             // query result not decodable into its field
+	KindEncode        ErrorKind = "encode"             // field value failing to encode, see SchemaMarshaler
+)
+
+// FieldError reports the failure to convert the field at Path, the dotted
+// column names leading to it. The cause is wrapped, so errors.Is and errors.As
+// see through it:
+//
+//	var fe *FieldError
+//	if errors.As(err, &fe) && fe.Kind == KindArrayOfArray { ... }
+//	if errors.Is(err, ErrArrayOfArray) { ... }
+//
+// errors.Is also matches a FieldError target by its Kind and, if set, its Path:
+//
+//	errors.Is(err, &FieldError{Kind: KindCycle})
+type FieldError struct {
+	Path    string
+	Kind    ErrorKind
+	Wrapped error
+}
+
+func (e *FieldError) Error() string {
+	if e.Path == "" {
+		return e.Wrapped.Error()
+	}
+	return e.Path + ": " + e.Wrapped.Error()
+}
+
+func (e *FieldError) Unwrap() error {
+	return e.Wrapped
+}
+
+func (e *FieldError) Is(target error) bool {
+	t, ok := target.(*FieldError)
+	if !ok {
+		return false
+	}
+	return (t.Kind == "" || t.Kind == e.Kind) && (t.Path == "" || t.Path == e.Path)
+}
+
+// fieldError returns a FieldError of the given kind for the field being
+// converted; toSchema sets its path.
+func fieldError(kind ErrorKind, format string, args ...interface{}) *FieldError {
+	return &FieldError{Kind: kind, Wrapped: fmt.Errorf(format, args...)}
+}
+
+// wrapFieldError prefixes the path of err with the column name, classifying
+// errors which are not FieldErrors yet.
+func wrapFieldError(name string, err error) error {
+	var fe *FieldError
+	if !errors.As(err, &fe) {
+		fe = &FieldError{Kind: KindTag, Wrapped: err}
+		var inconvertible *ErrInconvertibleType
+		switch {
+		case errors.Is(err, ErrArrayOfArray):
+			fe.Kind = KindArrayOfArray
+		case errors.As(err, &inconvertible):
+			fe.Kind = KindInconvertible
+		}
+	}
+	if fe.Path == "" {
+		fe.Path = name
+	} else {
+		fe.Path = name + "." + fe.Path
+	}
+	return fe
+}
+
+// enterType marks the struct type t as being converted, failing if it already
+// is, which would recurse forever. The returned func unmarks it.
+func (o *options) enterType(t reflect.Type) (func(), error) {
+	if o.visiting[t] {
+		return nil, &FieldError{Kind: KindCycle, Wrapped: fmt.Errorf("%w %s", ErrCycle, t)}
+	}
+	o.visiting[t] = true
+	return func() { delete(o.visiting, t) }, nil
+}
@@ -0,0 +1,103 @@
+package bqschema
+
+import (
+	"errors"
+
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+
+	"google.golang.org/api/bigquery/v2"
+)
+
+type errorsNode struct {
+	Name     string        `json:"name"`
+	Children []errorsNode  `json:"children"`
+	Parent   *errorsParent `json:"parent"`
+}
+
+type errorsParent struct {
+	Node *errorsNode `json:"node"`
+}
+
+var _ = Describe("FieldError", func() {
+	It("should report the path of the failing field", func() {
+		_, err := ToSchema(struct {
+			Order struct {
+				Lines [][]int `json:"lines"`
+			} `json:"order"`
+		}{})
+		Expect(err).To(MatchError("order.lines: Array of Arrays not allowed"))
+
+		var fe *FieldError
+		Expect(errors.As(err, &fe)).To(BeTrue())
+		Expect(fe.Path).To(Equal("order.lines"))
+		Expect(fe.Kind).To(Equal(KindArrayOfArray))
+		Expect(errors.Is(err, ErrArrayOfArray)).To(BeTrue())
+		Expect(errors.Is(err, &FieldError{Kind: KindArrayOfArray})).To(BeTrue())
+		Expect(errors.Is(err, &FieldError{Path: "order.lines"})).To(BeTrue())
+		Expect(errors.Is(err, &FieldError{Path: "order", Kind: KindArrayOfArray})).To(BeFalse())
+		Expect(errors.Is(err, &FieldError{Kind: KindCycle})).To(BeFalse())
+	})
+
+	It("should classify failures by kind", func() {
+		table := [][]interface{}{
+			[]interface{}{struct{ C chan int }{}, KindInconvertible, "C: inconvertible type: chan int"},
+			[]interface{}{struct {
+				A int `bigquery:",collation=und:ci"`
+			}{}, KindTag, "A: collation not allowed for integer column"},
+			[]interface{}{struct{ N uint64 }{}, KindLossy, "N: uint64 converted to integer; values above 9223372036854775807 overflow"},
+			[]interface{}{struct{ note string }{}, KindStrict, "note: unexported field skipped; tag it `bqschema:\"export\"` or `bigquery:\"-\"`"},
+		}
+		for _, data := range table {
+			_, err := ToSchema(data[0], WithStrict())
+			Expect(err).To(MatchError(data[2]))
+			Expect(errors.Is(err, &FieldError{Kind: data[1].(ErrorKind)})).To(BeTrue())
+		}
+
+		_, err := ToSchema(struct{ C chan int }{})
+		var inconvertible *ErrInconvertibleType
+		Expect(errors.As(err, &inconvertible)).To(BeTrue())
+		Expect(inconvertible.TypeName).To(Equal("chan int"))
+	})
+
+	It("should reject recursive types", func() {
+		_, err := ToSchema(errorsNode{})
+		Expect(err).To(MatchError("children: recursive type bqschema.errorsNode"))
+		Expect(errors.Is(err, ErrCycle)).To(BeTrue())
+		Expect(errors.Is(err, &FieldError{Kind: KindCycle})).To(BeTrue())
+
+		_, err = ToSchema(errorsParent{})
+		Expect(err).To(MatchError("node.children: recursive type bqschema.errorsNode"))
+	})
+
+	It("should still convert a struct used by several fields", func() {
+		type address struct{ City string }
+		_, err := ToSchema(struct {
+			Billing  address
+			Shipping address
+		}{})
+		Expect(err).To(BeNil())
+	})
+
+	It("should report duplicate columns after flattening", func() {
+		_, err := ToSchema(struct {
+			A   struct{ B int }
+			A_B int
+		}{}, WithFlatten(0, "_"))
+		Expect(errors.Is(err, &FieldError{Path: "A_B", Kind: KindDuplicate})).To(BeTrue())
+	})
+
+	It("should match validation errors by kind and path", func() {
+		schema := &bigquery.TableSchema{Fields: []*bigquery.TableFieldSchema{
+			&bigquery.TableFieldSchema{Mode: "required", Name: "id", Type: "integer"},
+		}}
+		err := ValidateValue(schema, map[string]interface{}{})
+		Expect(errors.Is(err, &FieldError{Kind: KindValidation})).To(BeTrue())
+		Expect(errors.Is(err, &FieldError{Path: "id", Kind: KindValidation})).To(BeTrue())
+		Expect(errors.Is(err, &FieldError{Kind: KindTag})).To(BeFalse())
+
+		var ve *ValidationError
+		Expect(errors.As(err, &ve)).To(BeTrue())
+		Expect(ve.Reason).To(Equal("required field is missing"))
+	})
+})
@@ -0,0 +1,88 @@
+package bqschema
+
+import (
+	"errors"
+	"fmt"
+	"reflect"
+	"strings"
+
+	"google.golang.org/api/bigquery/v2"
+)
+
+// ToExternalDataConfiguration builds the definition of an external table over
+// the files at uris, in the given source format, with the schema of src
+// converted with opts. JSON stands for NEWLINE_DELIMITED_JSON. AVRO, PARQUET
+// and ORC files describe their own schema, which BigQuery reads instead.
+//
+// Fields tagged with the hive attribute are hive partition keys, read from the
+// paths of the files rather than their contents. They are left out of the
+// schema and declared, in field order, by custom hive partitioning options
+// whose source URI prefix is the part of the first URI before any wildcard:
+//
+//	type event struct {
+//		Day    string `json:"dt" bigquery:",hive,type=DATE"`
+//		Region string `json:"region" bigquery:",hive"`
+//		ID     int64  `json:"id"`
+//	}
+//
+// reads gs://bucket/events/* as partitioned by gs://bucket/events/{dt:DATE}/{region:STRING}.
+// Keys are STRING, INTEGER, DATE or TIMESTAMP columns, named as tagged under
+// opts.
+func ToExternalDataConfiguration(src interface{}, format string, uris []string, opts ...Option) (*bigquery.ExternalDataConfiguration, error) {
+	schema, err := ToSchema(src, opts...)
+	if err != nil {
+		return nil, err
+	}
+
+	format = strings.ToUpper(format)
+	if format == "JSON" {
+		format = "NEWLINE_DELIMITED_JSON"
+	}
+	config := &bigquery.ExternalDataConfiguration{
+		SourceFormat: format,
+		SourceUris:   uris,
+	}
+
+	keys := make(map[string]bool)
+	for _, fp := range newOptions(opts).plan(reflect.TypeOf(src)).fields {
+		if _, ok := fp.tag.attrs["hive"]; ok && !fp.tag.skip {
+			keys[strings.ToLower(fp.tag.name)] = true
+		}
+	}
+	if len(keys) > 0 {
+		if len(uris) == 0 {
+			return nil, errors.New("hive partitioning requires a source URI")
+		}
+		prefix := uris[0]
+		if i := strings.IndexAny(prefix, "*?["); i >= 0 {
+			prefix = prefix[:i]
+		}
+		prefix = strings.TrimSuffix(prefix[:strings.LastIndex(prefix, "/")+1], "/")
+
+		fields := make([]*bigquery.TableFieldSchema, 0, len(schema.Fields))
+		for _, field := range schema.Fields {
+			if !keys[strings.ToLower(field.Name)] {
+				fields = append(fields, field)
+				continue
+			}
+			switch t := canonicalType(field.Type); t {
+			case "string", "integer", "date", "timestamp":
+				prefix += fmt.Sprintf("/{%s:%s}", field.Name, strings.ToUpper(t))
+			default:
+				return nil, &FieldError{Path: field.Name, Kind: KindTag, Wrapped: fmt.Errorf("hive partition key of type %s", t)}
+			}
+		}
+		schema.Fields = fields
+		config.HivePartitioningOptions = &bigquery.HivePartitioningOptions{
+			Mode:            "CUSTOM",
+			SourceUriPrefix: prefix,
+		}
+	}
+
+	switch format {
+	case "AVRO", "PARQUET", "ORC":
+	default:
+		config.Schema = schema
+	}
+	return config, nil
+}
@@ -0,0 +1,77 @@
+package bqschema
+
+import (
+	"errors"
+
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+
+	"google.golang.org/api/bigquery/v2"
+)
+
+var _ = Describe("ToExternalDataConfiguration", func() {
+	type row struct {
+		ID   int64  `json:"id"`
+		Name string `json:"name,omitempty"`
+	}
+	type event struct {
+		Day    string `json:"dt" bigquery:",hive,type=DATE"`
+		ID     int64  `json:"id"`
+		Region string `json:"region" bigquery:",hive"`
+	}
+
+	It("should define an external table with the struct schema", func() {
+		config, err := ToExternalDataConfiguration(row{}, "json", []string{"gs://bucket/rows/*.json"})
+		Expect(err).To(BeNil())
+		Expect(config).To(Equal(&bigquery.ExternalDataConfiguration{
+			Schema:       MustToSchema(row{}),
+			SourceFormat: "NEWLINE_DELIMITED_JSON",
+			SourceUris:   []string{"gs://bucket/rows/*.json"},
+		}))
+
+		config, err = ToExternalDataConfiguration(row{}, "PARQUET", []string{"gs://bucket/rows/*"})
+		Expect(err).To(BeNil())
+		Expect(config.Schema).To(BeNil())
+		Expect(config.SourceFormat).To(Equal("PARQUET"))
+	})
+
+	It("should declare hive partition keys", func() {
+		config, err := ToExternalDataConfiguration(event{}, "CSV", []string{"gs://bucket/events/*"})
+		Expect(err).To(BeNil())
+		Expect(config.Schema.Fields).To(Equal([]*bigquery.TableFieldSchema{
+			&bigquery.TableFieldSchema{Mode: "required", Name: "id", Type: "integer"},
+		}))
+		Expect(config.HivePartitioningOptions).To(Equal(&bigquery.HivePartitioningOptions{
+			Mode:            "CUSTOM",
+			SourceUriPrefix: "gs://bucket/events/{dt:DATE}/{region:STRING}",
+		}))
+	})
+
+	It("should name hive partition keys with options", func() {
+		config, err := ToExternalDataConfiguration(struct {
+			Day string `db:"day" bigquery:",hive,type=DATE"`
+			ID  int64  `db:"id"`
+		}{}, "CSV", []string{"gs://bucket/t/*"}, WithTagPriority("db", "bigquery"))
+		Expect(err).To(BeNil())
+		Expect(config.Schema.Fields).To(Equal([]*bigquery.TableFieldSchema{
+			&bigquery.TableFieldSchema{Mode: "required", Name: "id", Type: "integer"},
+		}))
+		Expect(config.HivePartitioningOptions.SourceUriPrefix).To(Equal("gs://bucket/t/{day:DATE}"))
+	})
+
+	It("should reject hive partition keys of other types", func() {
+		_, err := ToExternalDataConfiguration(struct {
+			Ratio float64 `bigquery:",hive"`
+		}{}, "CSV", []string{"gs://bucket/t/*"})
+		Expect(err).To(MatchError("Ratio: hive partition key of type float"))
+		Expect(errors.Is(err, &FieldError{Kind: KindTag})).To(BeTrue())
+
+		_, err = ToExternalDataConfiguration(event{}, "CSV", nil)
+		Expect(err).To(MatchError("hive partitioning requires a source URI"))
+	})
+
+	It("should not define tables for non structs", func() {
+		_, err := ToExternalDataConfiguration(1, "CSV", nil)
+		Expect(err).To(Equal(ErrNotStruct))
+	})
+})
@@ -0,0 +1,79 @@
+package bqschema
+
+import (
+	"reflect"
+	"sync"
+)
+
+// fieldPlan is what converting a struct field requires that does not depend
+// on options: the field and its parsed tags, which name the column and declare
+// whether it is skipped or nullable. Column modes and types are not planned:
+// they depend on the default mode, type mappings and concrete types of each
+// conversion, and a Converter caches the schemas resolving them instead.
+type fieldPlan struct {
+	index int
+	sf    reflect.StructField
+	tag   fieldTag
+}
+
+// typePlan caches the field plans of a struct type and whether values of a
+// type encode as strings, as of a registry generation.
+type typePlan struct {
+	generation int
+	fields     []fieldPlan
+	stringType bool
+}
+
+var typePlans sync.Map // of reflect.Type to *typePlan
+
+// planOf returns the plan of type t, computing it once per registry generation
+// for schema conversion and row encoding to share.
+func planOf(t reflect.Type) *typePlan {
+	generation := registryGeneration()
+	if p, ok := typePlans.Load(t); ok && p.(*typePlan).generation == generation {
+		return p.(*typePlan)
+	}
+
+	p := newTypePlan(t, generation, nil)
+	typePlans.Store(t, p)
+	return p
+}
+
+// plan returns the plan of type t under the tag priority and string types of
+// o, for schema conversion, row encoding and result decoding to agree on them.
+// Options declaring neither share the plans of planOf; others cache their own.
+func (o *options) plan(t reflect.Type) *typePlan {
+	if o == nil || (o.tagPriority == nil && len(o.stringTypes) == 0) {
+		return planOf(t)
+	}
+	generation := registryGeneration()
+	if o.plans != nil {
+		if p, ok := o.plans.Load(t); ok && p.(*typePlan).generation == generation {
+			return p.(*typePlan)
+		}
+	}
+	p := newTypePlan(t, generation, o)
+	if o.plans != nil {
+		o.plans.Store(t, p)
+	}
+	return p
+}
+
+func newTypePlan(t reflect.Type, generation int, o *options) *typePlan {
+	p := &typePlan{
+		generation: generation,
+		stringType: o.stringType(t),
+	}
+	if t.Kind() == reflect.Struct {
+		priority := defaultTagPriority
+		if o != nil && o.tagPriority != nil {
+			priority = o.tagPriority
+		}
+		p.fields = make([]fieldPlan, t.NumField())
+		for i := range p.fields {
+			sf := t.Field(i)
+			p.fields[i] = fieldPlan{index: i, sf: sf, tag: parseFieldTagIn(sf, priority)}
+		}
+	}
+	return p
+}
@@ -0,0 +1,119 @@
+package bqschema
+
+import (
+	"errors"
+	"strings"
+
+	"google.golang.org/api/bigquery/v2"
+)
+
+// WithFlatten replaces records nested depth or more levels deep with their
+// fields, named by joining the record and field names with separator: with
+// WithFlatten(0, "_") an Address record becomes the columns address_city,
+// address_zip and so on, while WithFlatten(1, "_") keeps top level records and
+// flattens the records within them. Repeated records are kept, their fields
+// flattened as usual. Required fields of a nullable record become nullable.
+// Rows encoded with the option, by InsertStructs or an NDJSONWriter, are
+// flattened the same way; query results can not be decoded into the nested
+// structs.
+//
+// BigQuery column names may only contain letters, numbers and underscores
+// unless the table allows flexible column names.
+func WithFlatten(depth int, separator string) Option {
+	return func(o *options) {
+		o.flatten = true
+		o.flattenDepth = depth
+		o.flattenSeparator = separator
+	}
+}
+
+// flattenFields flattens the records of fields found at the given level, and below, per the options.
+func flattenFields(fields []*bigquery.TableFieldSchema, level int, o *options) ([]*bigquery.TableFieldSchema, error) {
+	flat := make([]*bigquery.TableFieldSchema, 0, len(fields))
+	names := make(map[string]bool, len(fields))
+	add := func(field *bigquery.TableFieldSchema) error {
+		name := strings.ToLower(field.Name)
+		if names[name] {
+			return &FieldError{Path: field.Name, Kind: KindDuplicate, Wrapped: errors.New("duplicate column after flattening")}
+		}
+		names[name] = true
+		flat = append(flat, field)
+		return nil
+	}
+
+	for _, field := range fields {
+		if canonicalType(field.Type) != "record" {
+			if err := add(field); err != nil {
+				return nil, err
+			}
+			continue
+		}
+
+		nested, err := flattenFields(field.Fields, level+1, o)
+		if err != nil {
+			return nil, err
+		}
+		if level < o.flattenDepth || isRepeated(field) {
+			field.Fields = nested
+			if err := add(field); err != nil {
+				return nil, err
+			}
+			continue
+		}
+
+		for _, f := range nested {
+			f.Name = field.Name + o.flattenSeparator + f.Name
+			if canonicalMode(field.Mode) != "required" && canonicalMode(f.Mode) == "required" {
+				f.Mode = "nullable"
+			}
+			if err := add(f); err != nil {
+				return nil, err
+			}
+		}
+	}
+	return flat, nil
+}
+
+// flattenRow flattens the records of row, encoded from a value of the type
+// converted to fields before flattening, as flattenFields flattens fields.
+func flattenRow(fields []*bigquery.TableFieldSchema, row map[string]interface{}, level int, o *options) map[string]interface{} {
+	flat := make(map[string]interface{}, len(row))
+	for _, field := range fields {
+		value, ok := row[field.Name]
+		if !ok {
+			continue
+		}
+		if canonicalType(field.Type) != "record" {
+			flat[field.Name] = value
+			continue
+		}
+
+		if isRepeated(field) {
+			if values, ok := value.([]interface{}); ok {
+				elems := make([]interface{}, len(values))
+				for i, v := range values {
+					elems[i] = v
+					if record, ok := v.(map[string]interface{}); ok {
+						elems[i] = flattenRow(field.Fields, record, level+1, o)
+					}
+				}
+				value = elems
+			}
+			flat[field.Name] = value
+			continue
+		}
+
+		record, _ := value.(map[string]interface{})
+		if level < o.flattenDepth {
+			if record != nil {
+				value = flattenRow(field.Fields, record, level+1, o)
+			}
+			flat[field.Name] = value
+			continue
+		}
+		for name, v := range flattenRow(field.Fields, record, level+1, o) {
+			flat[field.Name+o.flattenSeparator+name] = v
+		}
+	}
+	return flat
+}
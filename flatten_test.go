@@ -0,0 +1,102 @@
+package bqschema
+
+import (
+	"reflect"
+
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+
+	"google.golang.org/api/bigquery/v2"
+)
+
+var _ = Describe("WithFlatten", func() {
+	type geo struct {
+		Lat float64
+		Lng float64
+	}
+	type address struct {
+		City string
+		Geo  geo
+	}
+	type phone struct {
+		Number string
+		Geo    geo
+	}
+	type person struct {
+		Name    string
+		Address address
+		Phones  []phone
+	}
+
+	It("should flatten every record at depth zero", func() {
+		schema, err := ToSchema(person{}, WithFlatten(0, "_"))
+		Expect(err).To(BeNil())
+		Expect(schema.Fields).To(Equal([]*bigquery.TableFieldSchema{
+			&bigquery.TableFieldSchema{Mode: "required", Name: "Name", Type: "string"},
+			&bigquery.TableFieldSchema{Mode: "nullable", Name: "Address_City", Type: "string"},
+			&bigquery.TableFieldSchema{Mode: "nullable", Name: "Address_Geo_Lat", Type: "float"},
+			&bigquery.TableFieldSchema{Mode: "nullable", Name: "Address_Geo_Lng", Type: "float"},
+			&bigquery.TableFieldSchema{Mode: "repeated", Name: "Phones", Type: "record", Fields: []*bigquery.TableFieldSchema{
+				&bigquery.TableFieldSchema{Mode: "required", Name: "Number", Type: "string"},
+				&bigquery.TableFieldSchema{Mode: "nullable", Name: "Geo_Lat", Type: "float"},
+				&bigquery.TableFieldSchema{Mode: "nullable", Name: "Geo_Lng", Type: "float"},
+			}},
+		}))
+	})
+
+	It("should keep records above the depth", func() {
+		schema, err := ToSchema(person{}, WithFlatten(1, "__"))
+		Expect(err).To(BeNil())
+		Expect(schema.Fields[1]).To(Equal(&bigquery.TableFieldSchema{
+			Mode: "nullable", Name: "Address", Type: "record", Fields: []*bigquery.TableFieldSchema{
+				&bigquery.TableFieldSchema{Mode: "required", Name: "City", Type: "string"},
+				&bigquery.TableFieldSchema{Mode: "nullable", Name: "Geo__Lat", Type: "float"},
+				&bigquery.TableFieldSchema{Mode: "nullable", Name: "Geo__Lng", Type: "float"},
+			},
+		}))
+	})
+
+	It("should flatten rows like schemas", func() {
+		value := person{
+			Name:    "Ada",
+			Address: address{City: "London", Geo: geo{Lat: 51.5, Lng: -0.1}},
+			Phones:  []phone{{Number: "1", Geo: geo{Lat: 1, Lng: 2}}},
+		}
+		o := newOptions([]Option{WithFlatten(0, "_")})
+		encode, err := o.rowEncoder(reflect.TypeOf(value))
+		Expect(err).To(BeNil())
+		row, err := encode(reflect.ValueOf(value))
+		Expect(err).To(BeNil())
+		Expect(row).To(Equal(map[string]interface{}{
+			"Name":            "Ada",
+			"Address_City":    "London",
+			"Address_Geo_Lat": 51.5,
+			"Address_Geo_Lng": -0.1,
+			"Phones": []interface{}{map[string]interface{}{
+				"Number":  "1",
+				"Geo_Lat": float64(1),
+				"Geo_Lng": float64(2),
+			}},
+		}))
+		Expect(ValidateValue(MustToSchema(person{}, WithFlatten(0, "_")), row)).To(Succeed())
+
+		o = newOptions([]Option{WithFlatten(1, "__")})
+		encode, err = o.rowEncoder(reflect.TypeOf(value))
+		Expect(err).To(BeNil())
+		row, err = encode(reflect.ValueOf(value))
+		Expect(err).To(BeNil())
+		Expect(row["Address"]).To(Equal(map[string]interface{}{
+			"City":     "London",
+			"Geo__Lat": 51.5,
+			"Geo__Lng": -0.1,
+		}))
+	})
+
+	It("should reject flattened names that collide", func() {
+		_, err := ToSchema(struct {
+			Address_City string
+			Address      address
+		}{}, WithFlatten(0, "_"))
+		Expect(err).To(MatchError("Address_City: duplicate column after flattening"))
+	})
+})
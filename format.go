@@ -0,0 +1,54 @@
+package bqschema
+
+import (
+	"bytes"
+	"fmt"
+	"strings"
+	"text/tabwriter"
+
+	"google.golang.org/api/bigquery/v2"
+)
+
+// FormatSchema renders schema as an aligned tree of columns, one per line with
+// its name, type, mode and description, the fields of records indented below
+// them:
+//
+//	ID       INTEGER        REQUIRED
+//	Address  RECORD         NULLABLE  Where the user lives.
+//	  City   STRING         REQUIRED
+//	Price    NUMERIC(38,9)  NULLABLE
+func FormatSchema(schema *bigquery.TableSchema) string {
+	var buf bytes.Buffer
+	w := tabwriter.NewWriter(&buf, 0, 0, 2, ' ', 0)
+	formatFields(w, schema.Fields, "")
+	w.Flush()
+
+	lines := strings.Split(strings.TrimSuffix(buf.String(), "\n"), "\n")
+	for i, line := range lines {
+		lines[i] = strings.TrimRight(line, " ")
+	}
+	return strings.Join(lines, "\n") + "\n"
+}
+
+func formatFields(w *tabwriter.Writer, fields []*bigquery.TableFieldSchema, indent string) {
+	for _, field := range fields {
+		fmt.Fprintf(w, "%s%s\t%s\t%s\t%s\n", indent, field.Name, formatType(field), strings.ToUpper(canonicalMode(field.Mode)), field.Description)
+		formatFields(w, field.Fields, indent+"  ")
+	}
+}
+
+// formatType returns the type of field as written in the BigQuery console.
+func formatType(field *bigquery.TableFieldSchema) string {
+	t := strings.ToUpper(field.Type)
+	switch {
+	case field.RangeElementType != nil:
+		t += "<" + strings.ToUpper(field.RangeElementType.Type) + ">"
+	case field.Precision != 0 && field.Scale != 0:
+		t += fmt.Sprintf("(%d,%d)", field.Precision, field.Scale)
+	case field.Precision != 0:
+		t += fmt.Sprintf("(%d)", field.Precision)
+	case field.MaxLength != 0:
+		t += fmt.Sprintf("(%d)", field.MaxLength)
+	}
+	return t
+}
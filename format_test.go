@@ -0,0 +1,29 @@
+package bqschema
+
+import (
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+
+	"google.golang.org/api/bigquery/v2"
+)
+
+var _ = Describe("FormatSchema", func() {
+	It("should render an aligned tree of columns", func() {
+		schema := &bigquery.TableSchema{Fields: []*bigquery.TableFieldSchema{
+			&bigquery.TableFieldSchema{Mode: "required", Name: "ID", Type: "integer"},
+			&bigquery.TableFieldSchema{Name: "Address", Type: "RECORD", Description: "Where the user lives.", Fields: []*bigquery.TableFieldSchema{
+				&bigquery.TableFieldSchema{Mode: "required", Name: "City", Type: "string"},
+				&bigquery.TableFieldSchema{Mode: "repeated", Name: "Lines", Type: "string", MaxLength: 80},
+			}},
+			&bigquery.TableFieldSchema{Mode: "nullable", Name: "Price", Type: "numeric", Precision: 38, Scale: 9},
+			&bigquery.TableFieldSchema{Mode: "nullable", Name: "Stay", Type: "range", RangeElementType: &bigquery.TableFieldSchemaRangeElementType{Type: "date"}},
+		}}
+		Expect(FormatSchema(schema)).To(Equal("" +
+			"ID       INTEGER        REQUIRED\n" +
+			"Address  RECORD         NULLABLE  Where the user lives.\n" +
+			"  City   STRING         REQUIRED\n" +
+			"  Lines  STRING(80)     REPEATED\n" +
+			"Price    NUMERIC(38,9)  NULLABLE\n" +
+			"Stay     RANGE<DATE>    NULLABLE\n"))
+	})
+})
@@ -0,0 +1,156 @@
+package bqschema
+
+import (
+	"errors"
+	"fmt"
+	"reflect"
+	"sort"
+	"strings"
+
+	"google.golang.org/api/bigquery/v2"
+)
+
+// graphQLScalars maps column types to GraphQL scalars. Types missing from the
+// built in scalars, 64 bit integers included, are declared as custom scalars.
+var graphQLScalars = map[string]string{
+	"string":     "String",
+	"boolean":    "Boolean",
+	"float":      "Float",
+	"integer":    "Int64",
+	"numeric":    "Numeric",
+	"bignumeric": "BigNumeric",
+	"bytes":      "Bytes",
+	"timestamp":  "Timestamp",
+	"date":       "Date",
+	"time":       "Time",
+	"datetime":   "DateTime",
+	"geography":  "Geography",
+	"json":       "JSON",
+	"interval":   "Interval",
+	"range":      "Range",
+}
+
+// ToGraphQLType converts the schema of src converted with opts to a GraphQL
+// object type named after its type, in schema definition language. Required columns are non-null, repeated
+// columns are non-null lists of non-null elements, as BigQuery arrays hold no
+// null, and descriptions are kept. Records are object types named after their
+// parent type and column:
+//
+//	scalar Int64
+//
+//	type User {
+//	  id: Int64!
+//	  "Where the user lives."
+//	  address: UserAddress
+//	  tags: [String!]!
+//	}
+//
+//	type UserAddress {
+//	  city: String!
+//	}
+//
+// Column types GraphQL has no scalar for, INTEGER included as GraphQL Int has
+// 32 bits, are declared as custom scalars.
+//
+// GraphQL names are letters, digits and underscores not starting with a digit;
+// types and columns named otherwise, such as columns tagged with dashes, fail
+// to convert.
+func ToGraphQLType(src interface{}, opts ...Option) (string, error) {
+	schema, err := ToSchema(src, opts...)
+	if err != nil {
+		return "", err
+	}
+	name := reflect.TypeOf(src).Name()
+	if name == "" {
+		return "", errors.New("anonymous struct types have no GraphQL type name")
+	}
+	if !isGraphQLName(name) {
+		return "", fmt.Errorf("type name %q is not a valid GraphQL name", name)
+	}
+
+	g := &graphQLWriter{scalars: map[string]bool{}}
+	if err := g.object(name, "", schema.Fields); err != nil {
+		return "", err
+	}
+
+	scalars := make([]string, 0, len(g.scalars))
+	for scalar := range g.scalars {
+		scalars = append(scalars, scalar)
+	}
+	sort.Strings(scalars)
+	var b strings.Builder
+	for _, scalar := range scalars {
+		fmt.Fprintf(&b, "scalar %s\n", scalar)
+	}
+	if len(scalars) > 0 {
+		b.WriteString("\n")
+	}
+	b.WriteString(strings.Join(g.types, "\n"))
+	return b.String(), nil
+}
+
+type graphQLWriter struct {
+	types   []string
+	scalars map[string]bool // custom scalars used
+}
+
+// object writes the object type name, of the record at the column path prefix,
+// and, after it, those of its records.
+func (g *graphQLWriter) object(name, prefix string, fields []*bigquery.TableFieldSchema) error {
+	var b strings.Builder
+	i := len(g.types)
+	g.types = append(g.types, "")
+
+	fmt.Fprintf(&b, "type %s {\n", name)
+	for _, field := range fields {
+		if !isGraphQLName(field.Name) {
+			return &FieldError{Path: prefix + field.Name, Kind: KindTag, Wrapped: errors.New("not a valid GraphQL name")}
+		}
+		t := graphQLScalars[canonicalType(field.Type)]
+		switch {
+		case canonicalType(field.Type) == "record":
+			t = name + strings.ToUpper(field.Name[:1]) + field.Name[1:]
+			if err := g.object(t, prefix+field.Name+".", field.Fields); err != nil {
+				return err
+			}
+		case t == "":
+			t = strings.ToUpper(canonicalType(field.Type))
+			g.scalars[t] = true
+		case t != "String" && t != "Boolean" && t != "Float":
+			g.scalars[t] = true
+		}
+
+		switch canonicalMode(field.Mode) {
+		case "required":
+			t += "!"
+		case "repeated":
+			t = "[" + t + "!]!"
+		}
+		if field.Description != "" {
+			fmt.Fprintf(&b, "  %s\n", graphQLString(field.Description))
+		}
+		fmt.Fprintf(&b, "  %s: %s\n", field.Name, t)
+	}
+	b.WriteString("}\n")
+	g.types[i] = b.String()
+	return nil
+}
+
+// isGraphQLName reports whether s is a GraphQL name: /[_A-Za-z][_0-9A-Za-z]*/.
+func isGraphQLName(s string) bool {
+	for i, r := range s {
+		switch {
+		case r == '_', 'a' <= r && r <= 'z', 'A' <= r && r <= 'Z':
+		case i > 0 && '0' <= r && r <= '9':
+		default:
+			return false
+		}
+	}
+	return s != ""
+}
+
+// graphQLString quotes s as a GraphQL string value.
+func graphQLString(s string) string {
+	r := strings.NewReplacer(`\`, `\\`, `"`, `\"`, "\n", `\n`, "\r", `\r`, "\t", `\t`)
+	return `"` + r.Replace(s) + `"`
+}
@@ -0,0 +1,86 @@
+package bqschema
+
+import (
+	"errors"
+	"time"
+
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+)
+
+type graphQLAddress struct {
+	City string `json:"city"`
+	Zip  string `json:"zip,omitempty"`
+}
+
+type graphQLUser struct {
+	ID      int64          `json:"id"`
+	Name    string         `json:"name,omitempty" description:"Display name, \"as entered\"."`
+	Score   float64        `json:"score"`
+	Active  bool           `json:"active"`
+	Address graphQLAddress `json:"address"`
+	Visits  []struct {
+		At    time.Time `json:"at"`
+		Pages []string  `json:"pages"`
+	} `json:"visits"`
+	Price string `json:"price" bigquery:",type=NUMERIC"`
+}
+
+var _ = Describe("ToGraphQLType", func() {
+	It("should convert types to GraphQL object types", func() {
+		sdl, err := ToGraphQLType(graphQLUser{})
+		Expect(err).To(BeNil())
+		Expect(sdl).To(Equal(`scalar Int64
+scalar Numeric
+scalar Timestamp
+
+type graphQLUser {
+  id: Int64!
+  "Display name, \"as entered\"."
+  name: String
+  score: Float!
+  active: Boolean!
+  address: graphQLUserAddress
+  visits: [graphQLUserVisits!]!
+  price: Numeric!
+}
+
+type graphQLUserAddress {
+  city: String!
+  zip: String
+}
+
+type graphQLUserVisits {
+  at: Timestamp
+  pages: [String!]!
+}
+`))
+	})
+
+	It("should not convert anonymous types and non structs", func() {
+		_, err := ToGraphQLType(struct{ A int }{})
+		Expect(err).To(MatchError("anonymous struct types have no GraphQL type name"))
+		_, err = ToGraphQLType(1)
+		Expect(err).To(Equal(ErrNotStruct))
+	})
+
+	It("should reject names GraphQL does not allow", func() {
+		type Invalid struct {
+			Address struct {
+				PostCode string `json:"post-code"`
+			} `json:"address"`
+		}
+		_, err := ToGraphQLType(Invalid{})
+		Expect(err).To(MatchError("address.post-code: not a valid GraphQL name"))
+		Expect(errors.Is(err, &FieldError{Path: "address.post-code", Kind: KindTag})).To(BeTrue())
+		type Größe struct{ Value int }
+		_, err = ToGraphQLType(Größe{})
+		Expect(err).To(MatchError(`type name "Größe" is not a valid GraphQL name`))
+	})
+
+	It("should convert with options", func() {
+		sdl, err := ToGraphQLType(graphQLUser{}, WithDefaultMode(Nullable))
+		Expect(err).To(BeNil())
+		Expect(sdl).To(ContainSubstring("  id: Int64\n"))
+	})
+})
@@ -0,0 +1,118 @@
+package bqschema
+
+import (
+	"strings"
+
+	"google.golang.org/api/bigquery/v2"
+)
+
+// MergeInferred unions two schemas inferred from batches of rows, such as
+// successive batches of a JSON stream, into a schema accepting the rows of
+// both. Unlike Merge it never fails: conflicting columns are promoted to a type
+// holding the values of both.
+//
+//   - Columns are matched by name ignoring case and keep the name and position
+//     they have in a; columns only in b follow in their order in b.
+//   - Columns missing from either schema become nullable, as do columns
+//     nullable in either. Columns repeated in either become repeated.
+//   - INTEGER, NUMERIC and BIGNUMERIC promote to the wider of the two, and any
+//     of them with FLOAT to FLOAT.
+//   - A record and a scalar, or anything and JSON, promote to JSON. Records
+//     are merged recursively.
+//   - Any other pair of types, such as anything and STRING, or BOOLEAN and
+//     TIMESTAMP, promotes to STRING.
+//
+// Promotions only widen types, so merging batches one after the other
+// converges to the same columns whatever the order of the batches; a nil schema
+// has seen no rows and leaves the other unchanged.
+func MergeInferred(a, b *bigquery.TableSchema) *bigquery.TableSchema {
+	switch {
+	case a == nil && b == nil:
+		return &bigquery.TableSchema{}
+	case a == nil:
+		return &bigquery.TableSchema{Fields: copyFields(b.Fields)}
+	case b == nil:
+		return &bigquery.TableSchema{Fields: copyFields(a.Fields)}
+	}
+	return &bigquery.TableSchema{Fields: mergeInferredFields(a.Fields, b.Fields)}
+}
+
+func mergeInferredFields(a, b []*bigquery.TableFieldSchema) []*bigquery.TableFieldSchema {
+	inB := make(map[string]*bigquery.TableFieldSchema, len(b))
+	for _, field := range b {
+		inB[strings.ToLower(field.Name)] = field
+	}
+
+	merged := make([]*bigquery.TableFieldSchema, 0, len(a)+len(b))
+	seen := make(map[string]bool, len(a))
+	for _, field := range a {
+		key := strings.ToLower(field.Name)
+		seen[key] = true
+		if other, ok := inB[key]; ok {
+			merged = append(merged, mergeInferredField(field, other))
+		} else {
+			merged = append(merged, inferredMissing(field))
+		}
+	}
+	for _, field := range b {
+		if !seen[strings.ToLower(field.Name)] {
+			merged = append(merged, inferredMissing(field))
+		}
+	}
+	return merged
+}
+
+// inferredMissing returns a copy of a field missing from the other schema.
+func inferredMissing(field *bigquery.TableFieldSchema) *bigquery.TableFieldSchema {
+	c := copyField(field)
+	if !isRepeated(c) {
+		c.Mode = "nullable"
+	}
+	return c
+}
+
+func mergeInferredField(a, b *bigquery.TableFieldSchema) *bigquery.TableFieldSchema {
+	merged := copyField(a)
+	switch {
+	case isRepeated(a) || isRepeated(b):
+		merged.Mode = "repeated"
+	case canonicalMode(a.Mode) == "nullable" || canonicalMode(b.Mode) == "nullable":
+		merged.Mode = "nullable"
+	}
+	if merged.Description == "" {
+		merged.Description = b.Description
+	}
+
+	t := promoteInferred(canonicalType(a.Type), canonicalType(b.Type))
+	if t != canonicalType(a.Type) {
+		merged.Type = t
+		merged.Precision, merged.Scale, merged.MaxLength = 0, 0, 0
+	}
+	if t == "record" {
+		merged.Fields = mergeInferredFields(a.Fields, b.Fields)
+	} else {
+		merged.Fields = nil
+	}
+	return merged
+}
+
+// numericRank orders the numeric types promoted to the wider of two.
+var numericRank = map[string]int{"integer": 1, "numeric": 2, "bignumeric": 3, "float": 4}
+
+// promoteInferred returns the type holding the values of columns of types a
+// and b, both canonical.
+func promoteInferred(a, b string) string {
+	switch {
+	case a == b:
+		return a
+	case a == "json" || b == "json" || a == "record" || b == "record":
+		return "json"
+	case numericRank[a] > 0 && numericRank[b] > 0:
+		if numericRank[a] > numericRank[b] {
+			return a
+		}
+		return b
+	default:
+		return "string"
+	}
+}
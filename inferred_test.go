@@ -0,0 +1,127 @@
+package bqschema
+
+import (
+	"reflect"
+	"testing"
+
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+
+	"google.golang.org/api/bigquery/v2"
+)
+
+// promotions lists pairs of column types and the type MergeInferred promotes them to.
+var promotions = [][]string{
+	[]string{"integer", "float", "float"},
+	[]string{"INT64", "INTEGER", "INT64"},
+	[]string{"integer", "numeric", "numeric"},
+	[]string{"bignumeric", "numeric", "bignumeric"},
+	[]string{"numeric", "float", "float"},
+	[]string{"integer", "string", "string"},
+	[]string{"boolean", "timestamp", "string"},
+	[]string{"record", "string", "json"},
+	[]string{"json", "integer", "json"},
+}
+
+var _ = Describe("MergeInferred", func() {
+	schema := func(fields ...*bigquery.TableFieldSchema) *bigquery.TableSchema {
+		return &bigquery.TableSchema{Fields: fields}
+	}
+
+	It("should promote conflicting types", func() {
+		for _, data := range promotions {
+			merged := MergeInferred(
+				schema(&bigquery.TableFieldSchema{Mode: "nullable", Name: "v", Type: data[0]}),
+				schema(&bigquery.TableFieldSchema{Mode: "nullable", Name: "v", Type: data[1]}),
+			)
+			Expect(merged.Fields[0].Type).To(Equal(data[2]), "%s+%s", data[0], data[1])
+			reversed := MergeInferred(
+				schema(&bigquery.TableFieldSchema{Mode: "nullable", Name: "v", Type: data[1]}),
+				schema(&bigquery.TableFieldSchema{Mode: "nullable", Name: "v", Type: data[0]}),
+			)
+			Expect(canonicalType(reversed.Fields[0].Type)).To(Equal(canonicalType(data[2])), "%s+%s", data[1], data[0])
+		}
+	})
+
+	It("should union columns and merge records", func() {
+		a := schema(
+			&bigquery.TableFieldSchema{Mode: "required", Name: "id", Type: "integer"},
+			&bigquery.TableFieldSchema{Mode: "required", Name: "user", Type: "record", Fields: []*bigquery.TableFieldSchema{
+				&bigquery.TableFieldSchema{Mode: "required", Name: "name", Type: "string"},
+			}},
+			&bigquery.TableFieldSchema{Mode: "nullable", Name: "tag", Type: "string"},
+		)
+		b := schema(
+			&bigquery.TableFieldSchema{Mode: "required", Name: "ID", Type: "float"},
+			&bigquery.TableFieldSchema{Mode: "repeated", Name: "tag", Type: "string"},
+			&bigquery.TableFieldSchema{Mode: "required", Name: "user", Type: "record", Fields: []*bigquery.TableFieldSchema{
+				&bigquery.TableFieldSchema{Mode: "required", Name: "name", Type: "string"},
+				&bigquery.TableFieldSchema{Mode: "required", Name: "age", Type: "integer"},
+			}},
+			&bigquery.TableFieldSchema{Mode: "required", Name: "seen", Type: "timestamp"},
+		)
+		Expect(MergeInferred(a, b).Fields).To(Equal([]*bigquery.TableFieldSchema{
+			&bigquery.TableFieldSchema{Mode: "required", Name: "id", Type: "float"},
+			&bigquery.TableFieldSchema{Mode: "required", Name: "user", Type: "record", Fields: []*bigquery.TableFieldSchema{
+				&bigquery.TableFieldSchema{Mode: "required", Name: "name", Type: "string"},
+				&bigquery.TableFieldSchema{Mode: "nullable", Name: "age", Type: "integer"},
+			}},
+			&bigquery.TableFieldSchema{Mode: "repeated", Name: "tag", Type: "string"},
+			&bigquery.TableFieldSchema{Mode: "nullable", Name: "seen", Type: "timestamp"},
+		}))
+		Expect(a.Fields[0].Type).To(Equal("integer"))
+	})
+
+	It("should converge whatever the order of the batches", func() {
+		batches := []*bigquery.TableSchema{
+			schema(&bigquery.TableFieldSchema{Mode: "nullable", Name: "v", Type: "integer"}),
+			schema(&bigquery.TableFieldSchema{Mode: "nullable", Name: "v", Type: "float"}),
+			schema(&bigquery.TableFieldSchema{Mode: "nullable", Name: "v", Type: "record", Fields: []*bigquery.TableFieldSchema{
+				&bigquery.TableFieldSchema{Mode: "nullable", Name: "x", Type: "integer"},
+			}}),
+			schema(&bigquery.TableFieldSchema{Mode: "nullable", Name: "w", Type: "boolean"}),
+		}
+		var forward, backward *bigquery.TableSchema
+		for i := range batches {
+			forward = MergeInferred(forward, batches[i])
+			backward = MergeInferred(backward, batches[len(batches)-1-i])
+		}
+		Expect(forward.Fields).To(ConsistOf(backward.Fields))
+		Expect(forward.Fields[0]).To(Equal(&bigquery.TableFieldSchema{Mode: "nullable", Name: "v", Type: "json"}))
+		Expect(MergeInferred(forward, forward)).To(Equal(forward))
+		Expect(MergeInferred(nil, nil)).To(Equal(&bigquery.TableSchema{}))
+	})
+})
+
+// FuzzMergeInferred checks that merging columns of any types and modes
+// converges: the result does not depend on the order of the schemas, and
+// merging it again with either of them, or with itself, changes nothing.
+func FuzzMergeInferred(f *testing.F) {
+	for i, data := range promotions {
+		f.Add(data[0], data[1], uint8(i), uint8(i+1))
+	}
+	modes := []string{"", "nullable", "required", "repeated", "REQUIRED"}
+	column := func(t string, mode uint8) *bigquery.TableSchema {
+		field := &bigquery.TableFieldSchema{Mode: modes[int(mode)%len(modes)], Name: "v", Type: t}
+		if canonicalType(t) == "record" {
+			field.Fields = []*bigquery.TableFieldSchema{&bigquery.TableFieldSchema{Name: "x", Type: t + "x"}}
+		}
+		return &bigquery.TableSchema{Fields: []*bigquery.TableFieldSchema{field}}
+	}
+	f.Fuzz(func(t *testing.T, typeA, typeB string, modeA, modeB uint8) {
+		a, b := column(typeA, modeA), column(typeB, modeB)
+		merged, reversed := MergeInferred(a, b), MergeInferred(b, a)
+		if len(merged.Fields) != 1 || len(reversed.Fields) != 1 {
+			t.Fatalf("%q+%q: got %d and %d columns", typeA, typeB, len(merged.Fields), len(reversed.Fields))
+		}
+		m, r := merged.Fields[0], reversed.Fields[0]
+		if canonicalType(m.Type) != canonicalType(r.Type) || canonicalMode(m.Mode) != canonicalMode(r.Mode) {
+			t.Fatalf("%q+%q: %s %s, reversed %s %s", typeA, typeB, m.Mode, m.Type, r.Mode, r.Type)
+		}
+		for _, schema := range []*bigquery.TableSchema{a, b, merged} {
+			if again := MergeInferred(merged, schema); !reflect.DeepEqual(again, merged) {
+				t.Fatalf("%q+%q: merging %s again changed %s to %s", typeA, typeB, schema.Fields[0].Type, m.Type, again.Fields[0].Type)
+			}
+		}
+	})
+}
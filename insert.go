@@ -0,0 +1,172 @@
+package bqschema
+
+import (
+	"context"
+	"crypto/rand"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"reflect"
+	"time"
+
+	"google.golang.org/api/bigquery/v2"
+	"google.golang.org/api/googleapi"
+)
+
+const (
+	maxInsertRows     = 500
+	maxInsertBytes    = 10 << 20
+	maxInsertAttempts = 5
+	rowOverheadBytes  = 64 // insertId and JSON framing per row
+)
+
+var (
+	ErrNotSlice = errors.New("Can not insert non slices")
+
+	// insertBackoff is the initial delay between retries of a failed insertAll call.
+	insertBackoff = time.Second
+)
+
+// InsertReport describes the outcome of InsertStructs.
+type InsertReport struct {
+	Inserted  int
+	RowErrors []*RowError
+}
+
+// RowError reports the insert errors for a single row, identified by its index in the inserted slice.
+type RowError struct {
+	Index  int
+	Errors []*bigquery.ErrorProto
+}
+
+func (e *RowError) Error() string {
+	if len(e.Errors) == 0 {
+		return fmt.Sprintf("row %d: insert failed", e.Index)
+	}
+	return fmt.Sprintf("row %d: %s", e.Index, e.Errors[0].Message)
+}
+
+// InsertStructs streams a slice of structs into a table with insertAll requests,
+// chunked to stay within the BigQuery request limits. Retryable failures are
+// retried with exponential backoff; per row insert errors are reported against
+// the index of the row in rows. Nil elements of slice fields are dropped.
+// Rows are converted and encoded with opts, as ToSchema converts them.
+func InsertStructs(ctx context.Context, svc *bigquery.Service, project, dataset, table string, rows interface{}, opts ...Option) (*InsertReport, error) {
+	v := reflect.Indirect(reflect.ValueOf(rows))
+	if v.Kind() != reflect.Slice && v.Kind() != reflect.Array {
+		return nil, ErrNotSlice
+	}
+	elemType := pointerGuard(v.Type().Elem()).Type()
+	if elemType.Kind() != reflect.Struct {
+		return nil, ErrNotStruct
+	}
+	schema, err := ToSchema(reflect.Zero(elemType).Interface(), opts...)
+	if err != nil {
+		return nil, err
+	}
+	encode, err := newOptions(opts).rowEncoder(elemType)
+	if err != nil {
+		return nil, err
+	}
+
+	report := &InsertReport{}
+	var (
+		chunk []*bigquery.TableDataInsertAllRequestRows
+		start int
+		size  int
+	)
+	flush := func() error {
+		if len(chunk) == 0 {
+			return nil
+		}
+		err := insertChunk(ctx, svc, project, dataset, table, chunk, start, report)
+		chunk, size = nil, 0
+		return err
+	}
+
+	for i := 0; i < v.Len(); i++ {
+		elem := indirectValue(v.Index(i))
+		if !elem.IsValid() {
+			return report, fmt.Errorf("row %d: nil", i)
+		}
+		row, err := encode(elem)
+		if err != nil {
+			return report, fmt.Errorf("row %d: %w", i, err)
+		}
+		values, err := CoerceRow(schema, row)
+		if err != nil {
+			return report, fmt.Errorf("row %d: %w", i, err)
+		}
+		b, err := json.Marshal(values)
+		if err != nil {
+			return report, fmt.Errorf("row %d: %w", i, err)
+		}
+
+		n := len(b) + rowOverheadBytes
+		if len(chunk) == maxInsertRows || (len(chunk) > 0 && size+n > maxInsertBytes) {
+			if err := flush(); err != nil {
+				return report, err
+			}
+		}
+		if len(chunk) == 0 {
+			start = i
+		}
+		chunk = append(chunk, &bigquery.TableDataInsertAllRequestRows{
+			InsertId: insertID(),
+			Json:     values,
+		})
+		size += n
+	}
+	return report, flush()
+}
+
+func insertChunk(ctx context.Context, svc *bigquery.Service, project, dataset, table string, rows []*bigquery.TableDataInsertAllRequestRows, start int, report *InsertReport) error {
+	req := &bigquery.TableDataInsertAllRequest{Rows: rows}
+
+	var (
+		resp *bigquery.TableDataInsertAllResponse
+		err  error
+	)
+	backoff := insertBackoff
+	for attempt := 1; ; attempt++ {
+		resp, err = svc.Tabledata.InsertAll(project, dataset, table, req).Context(ctx).Do()
+		if err == nil || !retryable(err) || attempt == maxInsertAttempts {
+			break
+		}
+		select {
+		case <-time.After(backoff):
+		case <-ctx.Done():
+			return ctx.Err()
+		}
+		backoff *= 2
+	}
+	if err != nil {
+		return err
+	}
+
+	failed := map[int]bool{}
+	for _, ie := range resp.InsertErrors {
+		index := start + int(ie.Index)
+		failed[index] = true
+		report.RowErrors = append(report.RowErrors, &RowError{Index: index, Errors: ie.Errors})
+	}
+	report.Inserted += len(rows) - len(failed)
+	return nil
+}
+
+func retryable(err error) bool {
+	var apiErr *googleapi.Error
+	if !errors.As(err, &apiErr) {
+		return false
+	}
+	return apiErr.Code == http.StatusTooManyRequests || apiErr.Code >= http.StatusInternalServerError
+}
+
+// insertID returns a random ID letting BigQuery deduplicate rows sent again by a retry.
+func insertID() string {
+	b := make([]byte, 16)
+	rand.Read(b)
+	return hex.EncodeToString(b)
+}
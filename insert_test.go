@@ -0,0 +1,144 @@
+package bqschema
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"reflect"
+	"time"
+
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+
+	"google.golang.org/api/bigquery/v2"
+	"google.golang.org/api/option"
+)
+
+var _ = Describe("InsertStructs", func() {
+	type row struct {
+		A int
+		B string `json:"b,omitempty"`
+	}
+
+	var (
+		server   *httptest.Server
+		svc      *bigquery.Service
+		requests []*bigquery.TableDataInsertAllRequest
+		failures int
+		backoff  time.Duration
+	)
+
+	BeforeEach(func() {
+		requests = nil
+		failures = 0
+		backoff = insertBackoff
+		insertBackoff = time.Millisecond
+		server = httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			defer GinkgoRecover()
+			Expect(r.URL.Path).To(Equal("/projects/p/datasets/d/tables/t/insertAll"))
+			if failures > 0 {
+				failures--
+				w.WriteHeader(http.StatusServiceUnavailable)
+				return
+			}
+
+			req := &bigquery.TableDataInsertAllRequest{}
+			Expect(json.NewDecoder(r.Body).Decode(req)).To(Succeed())
+			requests = append(requests, req)
+
+			resp := &bigquery.TableDataInsertAllResponse{}
+			for i, row := range req.Rows {
+				if row.Json["b"] == "bad" {
+					resp.InsertErrors = append(resp.InsertErrors, &bigquery.TableDataInsertAllResponseInsertErrors{
+						Index:  int64(i),
+						Errors: []*bigquery.ErrorProto{&bigquery.ErrorProto{Reason: "invalid", Message: "bad row"}},
+					})
+				}
+			}
+			json.NewEncoder(w).Encode(resp)
+		}))
+
+		var err error
+		svc, err = bigquery.NewService(context.Background(), option.WithEndpoint(server.URL), option.WithoutAuthentication())
+		Expect(err).To(BeNil())
+	})
+
+	AfterEach(func() {
+		server.Close()
+		insertBackoff = backoff
+	})
+
+	It("should chunk rows and map insert errors back to slice indices", func() {
+		rows := make([]*row, 1200)
+		for i := range rows {
+			rows[i] = &row{A: i}
+		}
+		rows[3].B = "bad"
+		rows[1101].B = "bad"
+
+		report, err := InsertStructs(context.Background(), svc, "p", "d", "t", rows)
+		Expect(err).To(BeNil())
+		Expect(requests).To(HaveLen(3))
+		Expect(requests[0].Rows).To(HaveLen(500))
+		Expect(requests[2].Rows).To(HaveLen(200))
+		Expect(requests[2].Rows[0].Json["A"]).To(BeNumerically("==", 1000))
+		Expect(requests[0].Rows[0].InsertId).NotTo(BeEmpty())
+
+		Expect(report.Inserted).To(Equal(1198))
+		Expect(report.RowErrors).To(HaveLen(2))
+		Expect(report.RowErrors[0].Index).To(Equal(3))
+		Expect(report.RowErrors[1].Index).To(Equal(1101))
+		Expect(report.RowErrors[1].Error()).To(Equal("row 1101: bad row"))
+	})
+
+	It("should retry retryable errors", func() {
+		failures = 2
+		report, err := InsertStructs(context.Background(), svc, "p", "d", "t", []row{row{A: 1}})
+		Expect(err).To(BeNil())
+		Expect(report.Inserted).To(Equal(1))
+		Expect(requests).To(HaveLen(1))
+	})
+
+	It("should drop nil elements of slice fields", func() {
+		type item struct{ N int }
+		tag := "a"
+		_, err := InsertStructs(context.Background(), svc, "p", "d", "t", []struct {
+			Tags  []*string
+			Items []*item
+		}{{
+			Tags:  []*string{nil, &tag},
+			Items: []*item{{N: 1}, nil},
+		}})
+		Expect(err).To(BeNil())
+		Expect(requests[0].Rows[0].Json["Tags"]).To(Equal([]interface{}{"a"}))
+		Expect(requests[0].Rows[0].Json["Items"]).To(Equal([]interface{}{map[string]interface{}{"N": float64(1)}}))
+	})
+
+	It("should convert and encode rows with the options given", func() {
+		type ids struct {
+			ID registryUserID `json:"id"`
+		}
+		isUserID := func(t reflect.Type) bool { return t == reflect.TypeOf(registryUserID(0)) }
+		_, err := InsertStructs(context.Background(), svc, "p", "d", "t", []ids{{ID: 42}}, WithStringType(isUserID))
+		Expect(err).To(BeNil())
+		Expect(requests[0].Rows[0].Json["id"]).To(Equal("42"))
+	})
+
+	It("should report rows failing validation", func() {
+		_, err := InsertStructs(context.Background(), svc, "p", "d", "t", []struct {
+			N string `bigquery:",type=integer"`
+		}{{N: "one"}})
+		Expect(err).To(MatchError(HavePrefix("row 0: ")))
+		var errs ValidationErrors
+		Expect(errors.As(err, &errs)).To(BeTrue())
+	})
+
+	It("should not insert values that are not slices of structs", func() {
+		_, err := InsertStructs(context.Background(), svc, "p", "d", "t", row{})
+		Expect(err).To(Equal(ErrNotSlice))
+		_, err = InsertStructs(context.Background(), svc, "p", "d", "t", []int{1})
+		Expect(err).To(Equal(ErrNotStruct))
+	})
+})
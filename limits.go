@@ -0,0 +1,21 @@
+package bqschema
+
+// WithMaxDepth fails conversions of types whose records nest deeper than n
+// levels, a record column holding scalars being one level. BigQuery allows 15.
+// The error is a FieldError of kind KindLimit naming the offending record.
+// Zero, the default, sets no limit.
+func WithMaxDepth(n int) Option {
+	return func(o *options) {
+		o.maxDepth = n
+	}
+}
+
+// WithMaxFields fails conversions of types with more than n columns, counting
+// records and the fields they hold. BigQuery allows 10,000. The error is a
+// FieldError of kind KindLimit naming the first column over the limit. Zero,
+// the default, sets no limit.
+func WithMaxFields(n int) Option {
+	return func(o *options) {
+		o.maxFields = n
+	}
+}
@@ -0,0 +1,51 @@
+package bqschema
+
+import (
+	"errors"
+
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+)
+
+var _ = Describe("Complexity limits", func() {
+	type city struct {
+		Name string `json:"name"`
+	}
+	type address struct {
+		Street string `json:"street"`
+		City   city   `json:"city"`
+	}
+	type user struct {
+		ID        int       `json:"id"`
+		Address   address   `json:"address"`
+		Addresses []address `json:"addresses"`
+	}
+
+	It("should fail on records nested deeper than the maximum depth", func() {
+		_, err := ToSchema(user{}, WithMaxDepth(1))
+		Expect(err).To(MatchError("address.city: records nested deeper than 1 levels"))
+		Expect(errors.Is(err, &FieldError{Path: "address.city", Kind: KindLimit})).To(BeTrue())
+
+		_, err = ToSchema(user{}, WithMaxDepth(2))
+		Expect(err).To(BeNil())
+	})
+
+	It("should fail on more columns than the maximum", func() {
+		_, err := ToSchema(user{}, WithMaxFields(4))
+		Expect(err).To(MatchError("address.city.name: more than 4 columns"))
+		Expect(errors.Is(err, &FieldError{Kind: KindLimit})).To(BeTrue())
+
+		_, err = ToSchema(user{}, WithMaxFields(9))
+		Expect(err).To(BeNil())
+	})
+
+	It("should count the columns of each conversion afresh", func() {
+		c := NewConverter(WithMaxFields(9))
+		_, err := c.ToSchema(user{})
+		Expect(err).To(BeNil())
+		_, _, err = c.ToSchemaWithReport(user{})
+		Expect(err).To(BeNil())
+		_, err = c.ToSchema(address{})
+		Expect(err).To(BeNil())
+	})
+})
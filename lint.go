@@ -0,0 +1,197 @@
+package bqschema
+
+import (
+	"fmt"
+	"reflect"
+	"strings"
+	"time"
+
+	"google.golang.org/api/bigquery/v2"
+)
+
+var (
+	bigqueryAttributes = []string{"as", "collation", "enum", "hive", "roundingMode", "type"}
+	bqschemaOptions    = []string{"export", "key"}
+)
+
+// Problem is a mistake found by LintType in the declaration of a field.
+type Problem struct {
+	Path    string
+	Message string
+}
+
+func (p Problem) String() string {
+	return fmt.Sprintf("%s: %s", p.Path, p.Message)
+}
+
+// LintType checks the fields of the struct type t, and of the structs it nests,
+// for mistakes ToSchema would silently accept or fail on: misspelled tag options,
+// conflicting column names, invalid tag attributes and fields of types that can
+// not be converted. Lint types in unit tests to catch them before deploying:
+//
+//	Expect(bqschema.LintType(reflect.TypeOf(Event{}))).To(BeEmpty())
+//
+// Fields are named as by ToSchema with opts.
+func LintType(t reflect.Type, opts ...Option) []Problem {
+	t = pointerGuard(t).Type()
+	if t.Kind() != reflect.Struct {
+		return []Problem{{Path: t.String(), Message: ErrNotStruct.Error()}}
+	}
+	l := &linter{opts: newOptions(opts), seen: map[reflect.Type]bool{}}
+	l.lintStruct(t, "")
+	return l.problems
+}
+
+type linter struct {
+	opts     *options
+	seen     map[reflect.Type]bool // structs being linted, to stop at recursive types
+	problems []Problem
+}
+
+func (l *linter) add(path, format string, args ...interface{}) {
+	l.problems = append(l.problems, Problem{Path: path, Message: fmt.Sprintf(format, args...)})
+}
+
+func (l *linter) lintStruct(t reflect.Type, prefix string) {
+	if l.seen[t] {
+		l.add(strings.TrimSuffix(prefix, "."), "recursive type %s", t)
+		return
+	}
+	l.seen[t] = true
+	defer delete(l.seen, t)
+
+	columns := make(map[string]string, t.NumField())
+	for _, fp := range l.opts.plan(t).fields {
+		sf, tag := fp.sf, fp.tag
+		if tag.skip {
+			l.lintTags(sf, prefix+sf.Name)
+			if sf.PkgPath != "" && (sf.Tag.Get("json") != "" || sf.Tag.Get("bigquery") != "") {
+				l.add(prefix+sf.Name, "unexported field is skipped; tag it `bqschema:\"export\"` to convert it")
+			}
+			continue
+		}
+
+		path := prefix + tag.name
+		l.lintTags(sf, path)
+		if other, ok := columns[strings.ToLower(tag.name)]; ok {
+			l.add(path, "column name also used by field %s", other)
+		}
+		columns[strings.ToLower(tag.name)] = sf.Name
+		l.lintField(t, sf, tag, path)
+	}
+}
+
+func (l *linter) lintTags(sf reflect.StructField, path string) {
+	jsonTag, bqTag := sf.Tag.Get("json"), sf.Tag.Get("bigquery")
+
+	jt := strings.Split(jsonTag, ",")
+	for _, opt := range jt[1:] {
+		l.lintOption(path, "json option", opt, knownJSONOptions())
+	}
+	bt := strings.Split(bqTag, ",")
+	for _, attr := range bt[1:] {
+		l.lintOption(path, "bigquery attribute", strings.SplitN(attr, "=", 2)[0], bigqueryAttributes)
+	}
+	if bqschemaTag := sf.Tag.Get("bqschema"); bqschemaTag != "" {
+		for _, opt := range strings.Split(bqschemaTag, ",") {
+			l.lintOption(path, "bqschema option", opt, bqschemaOptions)
+		}
+	}
+
+	if jt[0] != "" && jt[0] != "-" && bt[0] != "" && bt[0] != "-" && jt[0] != bt[0] {
+		l.add(path, "bigquery name %q conflicts with json name %q", bt[0], jt[0])
+	}
+}
+
+// lintOption reports an option that is not one of known, suggesting the known option it misspells.
+func (l *linter) lintOption(path, what, opt string, known []string) {
+	if opt == "" {
+		return
+	}
+	for _, k := range known {
+		if opt == k {
+			return
+		}
+	}
+	for _, k := range known {
+		if strings.EqualFold(opt, k) || editDistance(opt, k) <= 2 {
+			l.add(path, "unknown %s %q, did you mean %q?", what, opt, k)
+			return
+		}
+	}
+	l.add(path, "unknown %s %q", what, opt)
+}
+
+func (l *linter) lintField(t reflect.Type, sf reflect.StructField, tag fieldTag, path string) {
+	if tag.accessor != "" {
+		if err := checkAccessor(t, sf, tag); err != nil {
+			l.add(path, "%s", err)
+		}
+	}
+
+	ft := pointerGuard(sf.Type).Type()
+	if isArray(ft) {
+		ft = pointerGuard(ft.Elem()).Type()
+	}
+	if l.nested(ft, tag) {
+		if err := applyAttributes(&bigquery.TableFieldSchema{Name: tag.name, Type: "record"}, tag); err != nil {
+			l.add(path, "%s", err)
+		}
+		l.lintStruct(ft, path+".")
+		return
+	}
+
+	tfs, err := fieldSchema(sf, reflect.New(sf.Type).Elem(), tag, l.opts)
+	if err == nil {
+		err = applyAttributes(tfs, tag)
+	}
+	if err != nil {
+		l.add(path, "%s", err)
+	}
+}
+
+// nested reports whether fields of type t convert to records.
+func (l *linter) nested(t reflect.Type, tag fieldTag) bool {
+	if t.Kind() != reflect.Struct || l.opts.stringType(t) || t.ConvertibleTo(reflect.TypeOf(time.Time{})) {
+		return false
+	}
+	if _, ok := tag.attrs["type"]; ok {
+		return false
+	}
+	if _, ok := l.opts.typeMapping(t); ok {
+		return false
+	}
+	_, ok := enumValues(t, tag)
+	return !ok
+}
+
+// editDistance returns the Levenshtein distance between a and b.
+func editDistance(a, b string) int {
+	prev := make([]int, len(b)+1)
+	for j := range prev {
+		prev[j] = j
+	}
+	for i := 1; i <= len(a); i++ {
+		cur := make([]int, len(b)+1)
+		cur[0] = i
+		for j := 1; j <= len(b); j++ {
+			cost := 1
+			if a[i-1] == b[j-1] {
+				cost = 0
+			}
+			cur[j] = min3(prev[j]+1, cur[j-1]+1, prev[j-1]+cost)
+		}
+		prev = cur
+	}
+	return prev[len(b)]
+}
+
+func min3(a, b, c int) int {
+	if b < a {
+		a = b
+	}
+	if c < a {
+		a = c
+	}
+	return a
+}
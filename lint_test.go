@@ -0,0 +1,62 @@
+package bqschema
+
+import (
+	"reflect"
+
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+)
+
+type lintNode struct {
+	Name     string
+	Children []lintNode
+}
+
+var _ = Describe("LintType", func() {
+	type address struct {
+		City    string `json:"city,omitmepty"`
+		Country string `bigquery:",colation=und:ci"`
+	}
+	type row struct {
+		ID      int    `json:"id" bigquery:"ident"`
+		Name    string `json:"name,omitempty,string"`
+		Other   string `json:"NAME"`
+		Price   string `bigquery:",type=numeric,roundingMode=ROUND_UP"`
+		Kind    string `bigquery:",tyep=date,flag"`
+		Ch      chan int
+		Address *address
+		hidden  string `bigquery:"hidden"`
+		Skipped string `json:"-"`
+	}
+
+	It("should find mistakes in the tags and types of fields", func() {
+		Expect(LintType(reflect.TypeOf(row{}))).To(Equal([]Problem{
+			{"ident", `bigquery name "ident" conflicts with json name "id"`},
+			{"NAME", "column name also used by field Name"},
+			{"Price", `invalid rounding mode "ROUND_UP"`},
+			{"Kind", `unknown bigquery attribute "tyep", did you mean "type"?`},
+			{"Kind", `unknown bigquery attribute "flag"`},
+			{"Ch", "inconvertible type: chan int"},
+			{"Address.city", `unknown json option "omitmepty", did you mean "omitempty"?`},
+			{"Address.Country", `unknown bigquery attribute "colation", did you mean "collation"?`},
+			{"hidden", "unexported field is skipped; tag it `bqschema:\"export\"` to convert it"},
+		}))
+	})
+
+	It("should find nothing wrong with well declared types", func() {
+		type good struct {
+			ID    int       `json:"id"`
+			Name  string    `json:"name,omitempty" bigquery:"name,collation=und:ci"`
+			Tags  []string  `json:"tags"`
+			Items []address `json:"-"`
+		}
+		Expect(LintType(reflect.TypeOf(&good{}))).To(BeEmpty())
+	})
+
+	It("should report recursive and non struct types", func() {
+		Expect(LintType(reflect.TypeOf(lintNode{}))).To(Equal([]Problem{
+			{"Children", "recursive type bqschema.lintNode"},
+		}))
+		Expect(LintType(reflect.TypeOf(1))).To(Equal([]Problem{{"int", "Can not convert non structs"}}))
+	})
+})
@@ -0,0 +1,75 @@
+package bqschema
+
+import (
+	"google.golang.org/api/bigquery/v2"
+)
+
+// LoadOption configures the load job built by NewLoadJobConfig.
+type LoadOption func(*bigquery.JobConfigurationLoad)
+
+// WithDestinationTable sets the table the load job writes to.
+func WithDestinationTable(project, dataset, table string) LoadOption {
+	return func(load *bigquery.JobConfigurationLoad) {
+		load.DestinationTable = &bigquery.TableReference{
+			ProjectId: project,
+			DatasetId: dataset,
+			TableId:   table,
+		}
+	}
+}
+
+// WithSourceFormat sets the format of the source files, NEWLINE_DELIMITED_JSON by default.
+func WithSourceFormat(format string) LoadOption {
+	return func(load *bigquery.JobConfigurationLoad) {
+		load.SourceFormat = format
+	}
+}
+
+// WithWriteDisposition sets the action taken when the destination table already exists,
+// such as WRITE_APPEND or WRITE_TRUNCATE.
+func WithWriteDisposition(disposition string) LoadOption {
+	return func(load *bigquery.JobConfigurationLoad) {
+		load.WriteDisposition = disposition
+	}
+}
+
+// WithCreateDisposition sets whether the load job may create the destination table,
+// such as CREATE_IF_NEEDED or CREATE_NEVER.
+func WithCreateDisposition(disposition string) LoadOption {
+	return func(load *bigquery.JobConfigurationLoad) {
+		load.CreateDisposition = disposition
+	}
+}
+
+// WithSchemaUpdateOptions allows the load job to update the destination table schema,
+// such as ALLOW_FIELD_ADDITION or ALLOW_FIELD_RELAXATION.
+func WithSchemaUpdateOptions(options ...string) LoadOption {
+	return func(load *bigquery.JobConfigurationLoad) {
+		load.SchemaUpdateOptions = append(load.SchemaUpdateOptions, options...)
+	}
+}
+
+// NewLoadJobConfig builds a load job configuration loading gcsURI with the schema of src.
+// Use the NewLoadJobConfig method of a Converter to convert src with options.
+func NewLoadJobConfig(src interface{}, gcsURI string, opts ...LoadOption) (*bigquery.JobConfigurationLoad, error) {
+	return defaultConverter.NewLoadJobConfig(src, gcsURI, opts...)
+}
+
+// NewLoadJobConfig builds a load job configuration loading gcsURI with the
+// schema of src converted by c.
+func (c *Converter) NewLoadJobConfig(src interface{}, gcsURI string, opts ...LoadOption) (*bigquery.JobConfigurationLoad, error) {
+	schema, err := c.ToSchema(src)
+	if err != nil {
+		return nil, err
+	}
+
+	load := &bigquery.JobConfigurationLoad{
+		Schema:       schema,
+		SourceFormat: "NEWLINE_DELIMITED_JSON",
+		SourceUris:   []string{gcsURI},
+	}
+	for _, opt := range opts {
+		opt(load)
+	}
+	return load, nil
+}
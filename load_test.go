@@ -0,0 +1,49 @@
+package bqschema
+
+import (
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+
+	"google.golang.org/api/bigquery/v2"
+)
+
+var _ = Describe("NewLoadJobConfig", func() {
+	type row struct {
+		A int
+	}
+
+	It("should default to loading newline delimited JSON with the struct schema", func() {
+		load, err := NewLoadJobConfig(row{}, "gs://bucket/file.json")
+		Expect(err).To(BeNil())
+		Expect(load.Schema).To(Equal(MustToSchema(row{})))
+		Expect(load.SourceFormat).To(Equal("NEWLINE_DELIMITED_JSON"))
+		Expect(load.SourceUris).To(Equal([]string{"gs://bucket/file.json"}))
+	})
+
+	It("should apply load options", func() {
+		load, err := NewLoadJobConfig(row{}, "gs://bucket/*.avro",
+			WithDestinationTable("p", "d", "t"),
+			WithSourceFormat("AVRO"),
+			WithWriteDisposition("WRITE_APPEND"),
+			WithCreateDisposition("CREATE_NEVER"),
+			WithSchemaUpdateOptions("ALLOW_FIELD_ADDITION", "ALLOW_FIELD_RELAXATION"),
+		)
+		Expect(err).To(BeNil())
+		Expect(load.DestinationTable).To(Equal(&bigquery.TableReference{ProjectId: "p", DatasetId: "d", TableId: "t"}))
+		Expect(load.SourceFormat).To(Equal("AVRO"))
+		Expect(load.WriteDisposition).To(Equal("WRITE_APPEND"))
+		Expect(load.CreateDisposition).To(Equal("CREATE_NEVER"))
+		Expect(load.SchemaUpdateOptions).To(Equal([]string{"ALLOW_FIELD_ADDITION", "ALLOW_FIELD_RELAXATION"}))
+	})
+
+	It("should convert the schema with the options of a Converter", func() {
+		load, err := NewConverter(WithDefaultMode(Nullable)).NewLoadJobConfig(row{}, "gs://bucket/file.json")
+		Expect(err).To(BeNil())
+		Expect(load.Schema.Fields[0].Mode).To(Equal("nullable"))
+	})
+
+	It("should not build a config for non structs", func() {
+		_, err := NewLoadJobConfig(1, "gs://bucket/file.json")
+		Expect(err).To(Equal(ErrNotStruct))
+	})
+})
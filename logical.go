@@ -0,0 +1,76 @@
+package bqschema
+
+import (
+	"fmt"
+
+	"google.golang.org/api/bigquery/v2"
+)
+
+// LogicalType describes how values of a BigQuery column type are represented
+// in the Avro, Parquet and Arrow formats BigQuery loads and exports, for
+// exporters to check that a conversion keeps the meaning of each column.
+// Precision and Scale are those of decimal types.
+type LogicalType struct {
+	BigQuery           string
+	AvroType           string
+	AvroLogicalType    string
+	ParquetType        string
+	ParquetLogicalType string
+	ArrowType          string
+	Precision          int64
+	Scale              int64
+}
+
+// String describes the representation in each format, logical annotations in parentheses.
+func (l LogicalType) String() string {
+	avro, parquet := l.AvroType, l.ParquetType
+	if l.AvroLogicalType != "" {
+		avro += " (" + l.AvroLogicalType + ")"
+	}
+	if l.ParquetLogicalType != "" {
+		parquet += " (" + l.ParquetLogicalType + ")"
+	}
+	return fmt.Sprintf("avro %s, parquet %s, arrow %s", avro, parquet, l.ArrowType)
+}
+
+var logicalTypes = map[string]LogicalType{
+	"integer":    {BigQuery: "integer", AvroType: "long", ParquetType: "INT64", ArrowType: "int64"},
+	"float":      {BigQuery: "float", AvroType: "double", ParquetType: "DOUBLE", ArrowType: "float64"},
+	"boolean":    {BigQuery: "boolean", AvroType: "boolean", ParquetType: "BOOLEAN", ArrowType: "bool"},
+	"string":     {BigQuery: "string", AvroType: "string", ParquetType: "BYTE_ARRAY", ParquetLogicalType: "STRING", ArrowType: "utf8"},
+	"bytes":      {BigQuery: "bytes", AvroType: "bytes", ParquetType: "BYTE_ARRAY", ArrowType: "binary"},
+	"numeric":    {BigQuery: "numeric", AvroType: "bytes", AvroLogicalType: "decimal", ParquetType: "FIXED_LEN_BYTE_ARRAY", ParquetLogicalType: "DECIMAL", ArrowType: "decimal128", Precision: 38, Scale: 9},
+	"bignumeric": {BigQuery: "bignumeric", AvroType: "bytes", AvroLogicalType: "decimal", ParquetType: "FIXED_LEN_BYTE_ARRAY", ParquetLogicalType: "DECIMAL", ArrowType: "decimal256", Precision: 76, Scale: 38},
+	"timestamp":  {BigQuery: "timestamp", AvroType: "long", AvroLogicalType: "timestamp-micros", ParquetType: "INT64", ParquetLogicalType: "TIMESTAMP(MICROS,true)", ArrowType: "timestamp[us, tz=UTC]"},
+	"datetime":   {BigQuery: "datetime", AvroType: "string", AvroLogicalType: "datetime", ParquetType: "INT64", ParquetLogicalType: "TIMESTAMP(MICROS,false)", ArrowType: "timestamp[us]"},
+	"date":       {BigQuery: "date", AvroType: "int", AvroLogicalType: "date", ParquetType: "INT32", ParquetLogicalType: "DATE", ArrowType: "date32"},
+	"time":       {BigQuery: "time", AvroType: "long", AvroLogicalType: "time-micros", ParquetType: "INT64", ParquetLogicalType: "TIME(MICROS,false)", ArrowType: "time64[us]"},
+	"geography":  {BigQuery: "geography", AvroType: "string", ParquetType: "BYTE_ARRAY", ParquetLogicalType: "STRING", ArrowType: "utf8"},
+	"json":       {BigQuery: "json", AvroType: "string", ParquetType: "BYTE_ARRAY", ParquetLogicalType: "JSON", ArrowType: "utf8"},
+	"record":     {BigQuery: "record", AvroType: "record", ParquetType: "group", ArrowType: "struct"},
+}
+
+// LogicalTypes returns the mapping table LogicalTypeOf uses, keyed by lower
+// case BigQuery column type. Decimal types have BigQuery's default precision
+// and scale. The returned map is a copy.
+func LogicalTypes() map[string]LogicalType {
+	table := make(map[string]LogicalType, len(logicalTypes))
+	for t, l := range logicalTypes {
+		table[t] = l
+	}
+	return table
+}
+
+// LogicalTypeOf returns the representation of the values of field, with the
+// precision and scale of parameterized decimal columns. Repeated columns are
+// represented as arrays of the returned type.
+func LogicalTypeOf(field *bigquery.TableFieldSchema) (LogicalType, bool) {
+	l, ok := logicalTypes[canonicalType(field.Type)]
+	if !ok {
+		return LogicalType{}, false
+	}
+	if l.Precision != 0 && field.Precision != 0 {
+		l.Precision, l.Scale = field.Precision, field.Scale
+	}
+	return l, true
+}
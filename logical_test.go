@@ -0,0 +1,53 @@
+package bqschema
+
+import (
+	"time"
+
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+
+	"google.golang.org/api/bigquery/v2"
+)
+
+var _ = Describe("Logical types", func() {
+	It("should map column types to their Avro, Parquet and Arrow representations", func() {
+		l, ok := LogicalTypeOf(&bigquery.TableFieldSchema{Type: "TIMESTAMP"})
+		Expect(ok).To(BeTrue())
+		Expect(l.AvroLogicalType).To(Equal("timestamp-micros"))
+		Expect(l.String()).To(Equal("avro long (timestamp-micros), parquet INT64 (TIMESTAMP(MICROS,true)), arrow timestamp[us, tz=UTC]"))
+
+		l, _ = LogicalTypeOf(&bigquery.TableFieldSchema{Type: "numeric", Precision: 10, Scale: 2})
+		Expect([]int64{l.Precision, l.Scale}).To(Equal([]int64{10, 2}))
+		l, _ = LogicalTypeOf(&bigquery.TableFieldSchema{Type: "bignumeric"})
+		Expect([]int64{l.Precision, l.Scale}).To(Equal([]int64{76, 38}))
+
+		_, ok = LogicalTypeOf(&bigquery.TableFieldSchema{Type: "interval"})
+		Expect(ok).To(BeFalse())
+	})
+
+	It("should return a copy of the mapping table", func() {
+		table := LogicalTypes()
+		Expect(table["date"].ParquetLogicalType).To(Equal("DATE"))
+		delete(table, "date")
+		Expect(LogicalTypes()).To(HaveKey("date"))
+	})
+
+	It("should report the annotations of converted columns", func() {
+		_, report, err := ToSchemaWithReport(struct {
+			At    time.Time
+			Price string `bigquery:",type=numeric"`
+			Name  string
+		}{})
+		Expect(err).To(BeNil())
+		var logical []string
+		for _, e := range report.Entries {
+			if e.Kind == ReportLogical {
+				logical = append(logical, e.String())
+			}
+		}
+		Expect(logical).To(Equal([]string{
+			"At: avro long (timestamp-micros), parquet INT64 (TIMESTAMP(MICROS,true)), arrow timestamp[us, tz=UTC]",
+			"Price: avro bytes (decimal), parquet FIXED_LEN_BYTE_ARRAY (DECIMAL), arrow decimal128; precision 38, scale 9",
+		}))
+	})
+})
@@ -0,0 +1,51 @@
+package bqschema
+
+import (
+	"reflect"
+	"sort"
+)
+
+// mapEntryType returns the struct type a map of type t converts as: maps are
+// repeated records of key and value columns, as BigQuery has no map type.
+// Keys must be of string or integer kinds.
+func mapEntryType(t reflect.Type) (reflect.Type, bool) {
+	switch t.Key().Kind() {
+	case reflect.String,
+		reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64,
+		reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64:
+	default:
+		return nil, false
+	}
+	return reflect.StructOf([]reflect.StructField{
+		{Name: "Key", Type: t.Key(), Tag: `json:"key"`},
+		{Name: "Value", Type: t.Elem(), Tag: `json:"value"`},
+	}), true
+}
+
+// mapEntries returns the entries of the map v as rows of its entry type, ordered by key.
+func (o *options) mapEntries(v reflect.Value) ([]interface{}, error) {
+	keys := v.MapKeys()
+	sort.Slice(keys, func(i, j int) bool {
+		switch keys[i].Kind() {
+		case reflect.String:
+			return keys[i].String() < keys[j].String()
+		case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64:
+			return keys[i].Int() < keys[j].Int()
+		default:
+			return keys[i].Uint() < keys[j].Uint()
+		}
+	})
+	entries := make([]interface{}, len(keys))
+	for i, key := range keys {
+		k, err := o.rowValue(key)
+		if err != nil {
+			return nil, wrapFieldError("key", err)
+		}
+		value, err := o.rowValue(v.MapIndex(key))
+		if err != nil {
+			return nil, wrapFieldError("value", err)
+		}
+		entries[i] = map[string]interface{}{"key": k, "value": value}
+	}
+	return entries, nil
+}
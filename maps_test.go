@@ -0,0 +1,113 @@
+package bqschema
+
+import (
+	"errors"
+	"reflect"
+
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+
+	"google.golang.org/api/bigquery/v2"
+)
+
+type mapsUserID int64
+
+type mapsLabel = string
+
+var _ = Describe("Maps and named scalar types", func() {
+	type point struct {
+		X int `json:"x"`
+	}
+	type account struct {
+		Owners []mapsUserID              `json:"owners"`
+		Labels []mapsLabel               `json:"labels"`
+		Scores map[string]mapsUserID     `json:"scores"`
+		Points map[mapsUserID][]point    `json:"points"`
+		Counts map[mapsLabel]*mapsUserID `json:"counts,omitempty"`
+	}
+
+	It("should convert named scalar types by their kind and maps to key and value records", func() {
+		schema, err := ToSchema(account{})
+		Expect(err).To(BeNil())
+		Expect(schema.Fields).To(Equal([]*bigquery.TableFieldSchema{
+			&bigquery.TableFieldSchema{Mode: "repeated", Name: "owners", Type: "integer"},
+			&bigquery.TableFieldSchema{Mode: "repeated", Name: "labels", Type: "string"},
+			&bigquery.TableFieldSchema{Mode: "repeated", Name: "scores", Type: "record", Fields: []*bigquery.TableFieldSchema{
+				&bigquery.TableFieldSchema{Mode: "required", Name: "key", Type: "string"},
+				&bigquery.TableFieldSchema{Mode: "required", Name: "value", Type: "integer"},
+			}},
+			&bigquery.TableFieldSchema{Mode: "repeated", Name: "points", Type: "record", Fields: []*bigquery.TableFieldSchema{
+				&bigquery.TableFieldSchema{Mode: "required", Name: "key", Type: "integer"},
+				&bigquery.TableFieldSchema{Mode: "repeated", Name: "value", Type: "record", Fields: []*bigquery.TableFieldSchema{
+					&bigquery.TableFieldSchema{Mode: "required", Name: "x", Type: "integer"},
+				}},
+			}},
+			&bigquery.TableFieldSchema{Mode: "repeated", Name: "counts", Type: "record", Fields: []*bigquery.TableFieldSchema{
+				&bigquery.TableFieldSchema{Mode: "required", Name: "key", Type: "string"},
+				&bigquery.TableFieldSchema{Mode: "required", Name: "value", Type: "integer"},
+			}},
+		}))
+	})
+
+	It("should reject maps with other keys and slices of maps", func() {
+		_, err := ToSchema(struct{ M map[float64]int }{})
+		Expect(err).To(MatchError("M: inconvertible type: map[float64]int"))
+		_, err = ToSchema(struct{ M []map[string]int }{})
+		Expect(errors.Is(err, ErrArrayOfArray)).To(BeTrue())
+	})
+
+	It("should encode maps as entries ordered by key", func() {
+		row, err := structToRow(reflect.ValueOf(account{
+			Owners: []mapsUserID{7},
+			Scores: map[string]mapsUserID{"b": 2, "a": 1},
+			Points: map[mapsUserID][]point{3: {{1}}, -1: nil},
+		}))
+		Expect(err).To(BeNil())
+		Expect(row["owners"]).To(Equal([]mapsUserID{7}))
+		Expect(row["scores"]).To(Equal([]interface{}{
+			map[string]interface{}{"key": "a", "value": mapsUserID(1)},
+			map[string]interface{}{"key": "b", "value": mapsUserID(2)},
+		}))
+		Expect(row["points"]).To(Equal([]interface{}{
+			map[string]interface{}{"key": mapsUserID(-1), "value": nil},
+			map[string]interface{}{"key": mapsUserID(3), "value": []interface{}{map[string]interface{}{"x": 1}}},
+		}))
+		Expect(row["counts"]).To(BeNil())
+
+		coerced, err := CoerceRow(MustToSchema(account{}), row)
+		Expect(err).To(BeNil())
+		Expect(coerced["scores"]).To(Equal([]bigquery.JsonValue{
+			map[string]bigquery.JsonValue{"key": "a", "value": int64(1)},
+			map[string]bigquery.JsonValue{"key": "b", "value": int64(2)},
+		}))
+	})
+
+	It("should decode key and value records into maps", func() {
+		schema := MustToSchema(account{})
+		entry := func(key, value string) interface{} {
+			return map[string]interface{}{"v": map[string]interface{}{"f": []interface{}{
+				map[string]interface{}{"v": key},
+				map[string]interface{}{"v": value},
+			}}}
+		}
+		var accounts []account
+		err := RowsToStructs(schema, []*bigquery.TableRow{
+			&bigquery.TableRow{F: []*bigquery.TableCell{
+				&bigquery.TableCell{V: []interface{}{map[string]interface{}{"v": "7"}}},
+				&bigquery.TableCell{V: []interface{}{}},
+				&bigquery.TableCell{V: []interface{}{entry("a", "1"), entry("b", "2")}},
+				&bigquery.TableCell{V: []interface{}{}},
+				&bigquery.TableCell{V: []interface{}{entry("c", "3")}},
+			}},
+		}, &accounts)
+		Expect(err).To(BeNil())
+		three := mapsUserID(3)
+		Expect(accounts).To(Equal([]account{{
+			Owners: []mapsUserID{7},
+			Labels: []mapsLabel{},
+			Scores: map[string]mapsUserID{"a": 1, "b": 2},
+			Points: map[mapsUserID][]point{},
+			Counts: map[mapsLabel]*mapsUserID{"c": &three},
+		}}))
+	})
+})
@@ -0,0 +1,130 @@
+package bqschema
+
+import (
+	"fmt"
+	"strings"
+
+	"google.golang.org/api/bigquery/v2"
+)
+
+// Merge unions the fields of several schemas, such as event variants sharing a table.
+// Fields missing from some of the schemas are relaxed to nullable, nested records
+// are merged recursively, and fields whose types or repetition conflict are an error.
+func Merge(schemas ...*bigquery.TableSchema) (*bigquery.TableSchema, error) {
+	lists := make([][]*bigquery.TableFieldSchema, 0, len(schemas))
+	for _, schema := range schemas {
+		if schema != nil {
+			lists = append(lists, schema.Fields)
+		}
+	}
+
+	fields, err := mergeFields(lists, "")
+	if err != nil {
+		return nil, err
+	}
+	return &bigquery.TableSchema{Fields: fields}, nil
+}
+
+func mergeFields(lists [][]*bigquery.TableFieldSchema, prefix string) ([]*bigquery.TableFieldSchema, error) {
+	var order []string
+	byName := map[string][]*bigquery.TableFieldSchema{}
+	for _, fields := range lists {
+		for _, field := range fields {
+			key := strings.ToLower(field.Name)
+			if _, ok := byName[key]; !ok {
+				order = append(order, key)
+			}
+			byName[key] = append(byName[key], field)
+		}
+	}
+
+	merged := make([]*bigquery.TableFieldSchema, 0, len(order))
+	for _, key := range order {
+		fields := byName[key]
+		field, err := mergeField(fields, prefix)
+		if err != nil {
+			return nil, err
+		}
+		if len(fields) < len(lists) && !isRepeated(field) {
+			field.Mode = "nullable"
+		}
+		merged = append(merged, field)
+	}
+	return merged, nil
+}
+
+func mergeField(fields []*bigquery.TableFieldSchema, prefix string) (*bigquery.TableFieldSchema, error) {
+	merged := copyField(fields[0])
+	path := prefix + merged.Name
+
+	subFields := make([][]*bigquery.TableFieldSchema, 0, len(fields))
+	for _, field := range fields {
+		if canonicalType(field.Type) != canonicalType(merged.Type) {
+			return nil, fmt.Errorf("%s: conflicting types %s and %s", path, strings.ToLower(merged.Type), strings.ToLower(field.Type))
+		}
+		if isRepeated(field) != isRepeated(merged) {
+			return nil, fmt.Errorf("%s: conflicting modes %s and %s", path, canonicalMode(merged.Mode), canonicalMode(field.Mode))
+		}
+		if canonicalMode(field.Mode) == "nullable" {
+			merged.Mode = field.Mode
+		}
+		if merged.Description == "" {
+			merged.Description = field.Description
+		}
+		subFields = append(subFields, field.Fields)
+	}
+
+	if canonicalType(merged.Type) == "record" {
+		var err error
+		if merged.Fields, err = mergeFields(subFields, path+"."); err != nil {
+			return nil, err
+		}
+	}
+	return merged, nil
+}
+
+// canonicalType normalizes the legacy and standard SQL names of a column type.
+func canonicalType(t string) string {
+	switch t = strings.ToLower(t); t {
+	case "int64":
+		return "integer"
+	case "float64":
+		return "float"
+	case "bool":
+		return "boolean"
+	case "struct":
+		return "record"
+	default:
+		return t
+	}
+}
+
+// canonicalMode normalizes a column mode; BigQuery treats an empty mode as nullable.
+func canonicalMode(mode string) string {
+	if mode == "" {
+		return "nullable"
+	}
+	return strings.ToLower(mode)
+}
+
+func isRepeated(field *bigquery.TableFieldSchema) bool {
+	return canonicalMode(field.Mode) == "repeated"
+}
+
+// copyField returns a deep copy of field.
+func copyField(field *bigquery.TableFieldSchema) *bigquery.TableFieldSchema {
+	c := *field
+	c.Fields = copyFields(field.Fields)
+	return &c
+}
+
+func copyFields(fields []*bigquery.TableFieldSchema) []*bigquery.TableFieldSchema {
+	if fields == nil {
+		return nil
+	}
+	c := make([]*bigquery.TableFieldSchema, len(fields))
+	for i, field := range fields {
+		c[i] = copyField(field)
+	}
+	return c
+}
@@ -0,0 +1,104 @@
+package bqschema
+
+import (
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+
+	"google.golang.org/api/bigquery/v2"
+)
+
+var _ = Describe("Merge", func() {
+	type click struct {
+		ID   string
+		URL  string
+		Tags []string
+		Meta struct {
+			Browser string
+		}
+	}
+	type purchase struct {
+		ID    string
+		Price float64
+		Meta  struct {
+			Currency string
+		}
+	}
+
+	It("should union fields, relaxing fields absent from some schemas", func() {
+		schema, err := Merge(MustToSchema(click{}), MustToSchema(purchase{}))
+		Expect(err).To(BeNil())
+		Expect(*schema).To(Equal(bigquery.TableSchema{
+			Fields: []*bigquery.TableFieldSchema{
+				&bigquery.TableFieldSchema{Mode: "required", Name: "ID", Type: "string"},
+				&bigquery.TableFieldSchema{Mode: "nullable", Name: "URL", Type: "string"},
+				&bigquery.TableFieldSchema{Mode: "repeated", Name: "Tags", Type: "string"},
+				&bigquery.TableFieldSchema{
+					Mode: "nullable",
+					Name: "Meta",
+					Type: "record",
+					Fields: []*bigquery.TableFieldSchema{
+						&bigquery.TableFieldSchema{Mode: "nullable", Name: "Browser", Type: "string"},
+						&bigquery.TableFieldSchema{Mode: "nullable", Name: "Currency", Type: "string"},
+					},
+				},
+				&bigquery.TableFieldSchema{Mode: "nullable", Name: "Price", Type: "float"},
+			},
+		}))
+	})
+
+	It("should treat legacy and standard type names as equal", func() {
+		schema, err := Merge(
+			&bigquery.TableSchema{Fields: []*bigquery.TableFieldSchema{&bigquery.TableFieldSchema{Mode: "REQUIRED", Name: "a", Type: "INT64"}}},
+			&bigquery.TableSchema{Fields: []*bigquery.TableFieldSchema{&bigquery.TableFieldSchema{Name: "A", Type: "INTEGER"}}},
+		)
+		Expect(err).To(BeNil())
+		Expect(schema.Fields).To(Equal([]*bigquery.TableFieldSchema{&bigquery.TableFieldSchema{Name: "a", Type: "INT64"}}))
+	})
+
+	It("should not modify the merged schemas", func() {
+		a := MustToSchema(click{})
+		Merge(a, MustToSchema(purchase{}))
+		Expect(a).To(Equal(MustToSchema(click{})))
+	})
+
+	Context("when fields can not be reconciled", func() {
+		table := [][]interface{}{
+			[]interface{}{
+				&bigquery.TableFieldSchema{Mode: "required", Name: "A", Type: "string"},
+				&bigquery.TableFieldSchema{Mode: "required", Name: "A", Type: "integer"},
+				"A: conflicting types string and integer",
+				"should error on conflicting types",
+			},
+			[]interface{}{
+				&bigquery.TableFieldSchema{Mode: "required", Name: "A", Type: "string"},
+				&bigquery.TableFieldSchema{Mode: "repeated", Name: "A", Type: "string"},
+				"A: conflicting modes required and repeated",
+				"should error on conflicting repetition",
+			},
+			[]interface{}{
+				&bigquery.TableFieldSchema{Mode: "nullable", Name: "R", Type: "record", Fields: []*bigquery.TableFieldSchema{
+					&bigquery.TableFieldSchema{Mode: "required", Name: "A", Type: "float"},
+				}},
+				&bigquery.TableFieldSchema{Mode: "nullable", Name: "R", Type: "record", Fields: []*bigquery.TableFieldSchema{
+					&bigquery.TableFieldSchema{Mode: "required", Name: "A", Type: "boolean"},
+				}},
+				"R.A: conflicting types float and boolean",
+				"should error on conflicts inside records",
+			},
+		}
+
+		for _, data := range table {
+			a := data[0].(*bigquery.TableFieldSchema)
+			b := data[1].(*bigquery.TableFieldSchema)
+			message := data[2].(string)
+			It(data[3].(string), func() {
+				_, err := Merge(
+					&bigquery.TableSchema{Fields: []*bigquery.TableFieldSchema{a}},
+					&bigquery.TableSchema{Fields: []*bigquery.TableFieldSchema{b}},
+				)
+				Expect(err).NotTo(BeNil())
+				Expect(err.Error()).To(Equal(message))
+			})
+		}
+	})
+})
@@ -0,0 +1,81 @@
+package bqschema
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"reflect"
+
+	"google.golang.org/api/bigquery/v2"
+)
+
+// NDJSONWriter writes rows as newline delimited JSON files load jobs accept.
+type NDJSONWriter struct {
+	enc      *json.Encoder
+	schema   *bigquery.TableSchema
+	opts     *options
+	encoders map[reflect.Type]func(reflect.Value) (map[string]interface{}, error)
+	rows     int
+}
+
+// NewNDJSONWriter returns a writer of rows of schema to w. Structs are encoded
+// with opts, which should be those schema was converted with. Writes are not
+// buffered; wrap w in a bufio.Writer when writing many rows.
+func NewNDJSONWriter(w io.Writer, schema *bigquery.TableSchema, opts ...Option) *NDJSONWriter {
+	enc := json.NewEncoder(w)
+	enc.SetEscapeHTML(false)
+	return &NDJSONWriter{
+		enc:      enc,
+		schema:   schema,
+		opts:     newOptions(opts),
+		encoders: map[reflect.Type]func(reflect.Value) (map[string]interface{}, error){},
+	}
+}
+
+// Write writes src, a struct, a pointer to a struct or a map keyed by column
+// name, as a line of JSON. Values are encoded as CoerceRow encodes them:
+// RFC3339 timestamps, base64 bytes and string encoded numerics. Rows not
+// conforming to the schema are not written; the error, prefixed with the index
+// of the row, wraps the ValidationErrors found.
+func (w *NDJSONWriter) Write(src interface{}) error {
+	i := w.rows
+	var row map[string]interface{}
+	if m, ok := src.(map[string]interface{}); ok {
+		row = m
+	} else {
+		v := indirectValue(reflect.ValueOf(src))
+		if !v.IsValid() || v.Kind() != reflect.Struct {
+			return ErrNotStruct
+		}
+		encode, ok := w.encoders[v.Type()]
+		if !ok {
+			var err error
+			if encode, err = w.opts.rowEncoder(v.Type()); err != nil {
+				return err
+			}
+			w.encoders[v.Type()] = encode
+		}
+		var err error
+		if row, err = encode(v); err != nil {
+			return fmt.Errorf("row %d: %w", i, err)
+		}
+	}
+
+	if err := ValidateValue(w.schema, row); err != nil {
+		return fmt.Errorf("row %d: %w", i, err)
+	}
+	values, err := CoerceRow(w.schema, row)
+	if err != nil {
+		return fmt.Errorf("row %d: %w", i, err)
+	}
+	if err := w.enc.Encode(values); err != nil {
+		return err
+	}
+	w.rows++
+	return nil
+}
+
+// Rows returns the number of rows written.
+func (w *NDJSONWriter) Rows() int {
+	return w.rows
+}
@@ -0,0 +1,64 @@
+package bqschema
+
+import (
+	"bytes"
+	"errors"
+	"time"
+
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+)
+
+var _ = Describe("NDJSONWriter", func() {
+	type address struct {
+		City string `json:"city"`
+	}
+	type event struct {
+		ID      int64     `json:"id"`
+		At      time.Time `json:"at"`
+		Payload []byte    `json:"payload,omitempty" bigquery:",type=BYTES"`
+		Amount  float64   `json:"amount" bigquery:",type=NUMERIC"`
+		Tags    []string  `json:"tags"`
+		Address *address  `json:"address"`
+		Note    *string   `json:"note,omitempty"`
+	}
+	schema := MustToSchema(event{})
+	at := time.Date(2024, 5, 1, 12, 30, 0, 500000000, time.FixedZone("CEST", 2*60*60))
+
+	It("should write rows as newline delimited JSON", func() {
+		var buf bytes.Buffer
+		w := NewNDJSONWriter(&buf, schema)
+		Expect(w.Write(event{ID: 1, At: at, Payload: []byte("<hi>"), Amount: 12.5, Tags: []string{"a"}, Address: &address{"Paris"}})).To(Succeed())
+		Expect(w.Write(&event{ID: 2, At: at})).To(Succeed())
+		Expect(w.Write(map[string]interface{}{"id": 3, "at": at, "amount": 1})).To(Succeed())
+		Expect(w.Rows()).To(Equal(3))
+		Expect(buf.String()).To(Equal(
+			`{"address":{"city":"Paris"},"amount":"12.5","at":"2024-05-01T10:30:00.5Z","id":1,"payload":"PGhpPg==","tags":["a"]}` + "\n" +
+				`{"amount":"0","at":"2024-05-01T10:30:00.5Z","id":2}` + "\n" +
+				`{"amount":"1","at":"2024-05-01T10:30:00.5Z","id":3}` + "\n"))
+	})
+
+	It("should encode structs with the options of the schema", func() {
+		var buf bytes.Buffer
+		w := NewNDJSONWriter(&buf, MustToSchema(event{}, WithFlatten(0, "_")), WithFlatten(0, "_"))
+		Expect(w.Write(event{ID: 1, At: at, Address: &address{"Paris"}})).To(Succeed())
+		Expect(w.Write(event{ID: 2, At: at})).To(Succeed())
+		Expect(buf.String()).To(Equal(
+			`{"address_city":"Paris","amount":"0","at":"2024-05-01T10:30:00.5Z","id":1}` + "\n" +
+				`{"amount":"0","at":"2024-05-01T10:30:00.5Z","id":2}` + "\n"))
+	})
+
+	It("should not write rows that do not conform to the schema", func() {
+		var buf bytes.Buffer
+		w := NewNDJSONWriter(&buf, schema)
+		err := w.Write(map[string]interface{}{"id": "x", "at": at, "amount": 1, "extra": true})
+		Expect(err).To(MatchError("row 0: id: can not coerce string to integer; extra: no such field in schema"))
+		Expect(errors.Is(err, &FieldError{Path: "extra", Kind: KindValidation})).To(BeTrue())
+
+		err = w.Write(map[string]interface{}{"at": at, "amount": 1})
+		Expect(err).To(MatchError("row 0: id: required field is missing"))
+		Expect(w.Write(1)).To(Equal(ErrNotStruct))
+		Expect(buf.Len()).To(BeZero())
+		Expect(w.Rows()).To(BeZero())
+	})
+})
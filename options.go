@@ -0,0 +1,107 @@
+package bqschema
+
+import (
+	"errors"
+	"fmt"
+	"reflect"
+	"strings"
+	"sync"
+
+	"google.golang.org/api/bigquery/v2"
+)
+
+// Mode is the mode of a BigQuery column.
+type Mode string
+
+const (
+	Required Mode = "required"
+	Nullable Mode = "nullable"
+	Repeated Mode = "repeated"
+)
+
+// Option configures how Go types are converted to BigQuery schemas.
+type Option func(*options)
+
+type options struct {
+	defaultMode   Mode
+	typeMappings  map[reflect.Type]*bigquery.TableFieldSchema
+	concreteTypes map[string]reflect.Type
+	stringTypes   []func(reflect.Type) bool
+	tagPriority   []string
+
+	flatten          bool
+	flattenDepth     int
+	flattenSeparator string
+
+	strict  bool
+	isMoney func(column string) bool
+
+	maxDepth  int
+	maxFields int
+
+	plans *sync.Map // of reflect.Type to *typePlan, if options change plans
+
+	report   *Report               // of the conversion in progress, if requested
+	visiting map[reflect.Type]bool // struct types being converted
+	columns  int                   // converted so far
+}
+
+func newOptions(opts []Option) *options {
+	o := &options{
+		defaultMode: Required,
+		isMoney:     isMoney,
+		plans:       &sync.Map{},
+	}
+	for _, opt := range opts {
+		opt(o)
+	}
+	return o
+}
+
+// clone returns a copy of o that options can be applied to without changing o.
+func (o *options) clone() *options {
+	c := *o
+	if o.typeMappings != nil {
+		c.typeMappings = make(map[reflect.Type]*bigquery.TableFieldSchema, len(o.typeMappings))
+		for t, field := range o.typeMappings {
+			c.typeMappings[t] = field
+		}
+	}
+	if o.concreteTypes != nil {
+		c.concreteTypes = make(map[string]reflect.Type, len(o.concreteTypes))
+		for name, t := range o.concreteTypes {
+			c.concreteTypes[name] = t
+		}
+	}
+	c.stringTypes = append([]func(reflect.Type) bool(nil), o.stringTypes...)
+	c.plans = &sync.Map{}
+	c.report = nil
+	c.visiting = nil
+	c.columns = 0
+	return &c
+}
+
+// ErrInvalidMode is returned converting types with a default mode other than
+// Required or Nullable.
+var ErrInvalidMode = errors.New("invalid default mode")
+
+// WithDefaultMode sets the mode of fields not tagged omitempty, Required or
+// Nullable, in any case; conversions with other modes fail with
+// ErrInvalidMode. The default is Required for compatibility;
+// WithDefaultMode(Nullable) matches BigQuery, which treats columns without a
+// mode as nullable.
+func WithDefaultMode(mode Mode) Option {
+	return func(o *options) {
+		o.defaultMode = Mode(strings.ToLower(string(mode)))
+	}
+}
+
+// checkMode verifies the default mode of o.
+func (o *options) checkMode() error {
+	switch o.defaultMode {
+	case Required, Nullable:
+		return nil
+	default:
+		return fmt.Errorf("%w %q; must be required or nullable", ErrInvalidMode, o.defaultMode)
+	}
+}
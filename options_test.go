@@ -0,0 +1,53 @@
+package bqschema
+
+import (
+	"errors"
+
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+
+	"google.golang.org/api/bigquery/v2"
+)
+
+var _ = Describe("Options", func() {
+	type sub struct {
+		B string
+	}
+	type row struct {
+		A   int
+		Sub sub
+		C   []string
+		D   string `json:"d,omitempty"`
+	}
+
+	It("should default fields to required", func() {
+		Expect(MustToSchema(row{})).To(Equal(MustToSchema(row{}, WithDefaultMode(Required))))
+		Expect(MustToSchema(row{}).Fields[0].Mode).To(Equal("required"))
+	})
+
+	It("should set the mode of fields not tagged omitempty", func() {
+		schema, err := ToSchema(row{}, WithDefaultMode(Nullable))
+		Expect(err).To(BeNil())
+		Expect(schema.Fields).To(Equal([]*bigquery.TableFieldSchema{
+			&bigquery.TableFieldSchema{Mode: "nullable", Name: "A", Type: "integer"},
+			&bigquery.TableFieldSchema{
+				Mode: "nullable",
+				Name: "Sub",
+				Type: "record",
+				Fields: []*bigquery.TableFieldSchema{
+					&bigquery.TableFieldSchema{Mode: "nullable", Name: "B", Type: "string"},
+				},
+			},
+			&bigquery.TableFieldSchema{Mode: "repeated", Name: "C", Type: "string"},
+			&bigquery.TableFieldSchema{Mode: "nullable", Name: "d", Type: "string"},
+		}))
+	})
+
+	It("should only accept required and nullable default modes", func() {
+		Expect(MustToSchema(row{}, WithDefaultMode("NULLABLE"))).To(Equal(MustToSchema(row{}, WithDefaultMode(Nullable))))
+		for _, mode := range []Mode{Repeated, "optional", ""} {
+			_, err := ToSchema(row{}, WithDefaultMode(mode))
+			Expect(errors.Is(err, ErrInvalidMode)).To(BeTrue(), string(mode))
+		}
+	})
+})
@@ -0,0 +1,114 @@
+package bqschema
+
+import (
+	"fmt"
+	"reflect"
+	"strconv"
+	"strings"
+
+	"google.golang.org/api/bigquery/v2"
+)
+
+// ToQueryParameters converts and encodes the fields of the struct src with opts
+// into named query parameters, one per column ToSchema would declare, with the
+// same names and types: records become STRUCT parameters and repeated fields
+// ARRAY parameters. Nil fields become NULL parameters of their type.
+func ToQueryParameters(src interface{}, opts ...Option) ([]*bigquery.QueryParameter, error) {
+	v := indirectValue(reflect.ValueOf(src))
+	if !v.IsValid() || v.Kind() != reflect.Struct {
+		return nil, ErrNotStruct
+	}
+	schema, err := ToSchema(v.Interface(), opts...)
+	if err != nil {
+		return nil, err
+	}
+	encode, err := newOptions(opts).rowEncoder(v.Type())
+	if err != nil {
+		return nil, err
+	}
+	encoded, err := encode(v)
+	if err != nil {
+		return nil, err
+	}
+	row, err := CoerceRow(schema, encoded)
+	if err != nil {
+		return nil, err
+	}
+
+	params := make([]*bigquery.QueryParameter, len(schema.Fields))
+	for i, field := range schema.Fields {
+		params[i] = &bigquery.QueryParameter{
+			Name:           field.Name,
+			ParameterType:  parameterType(field),
+			ParameterValue: parameterValue(field, row[field.Name]),
+		}
+	}
+	return params, nil
+}
+
+func parameterType(field *bigquery.TableFieldSchema) *bigquery.QueryParameterType {
+	pt := &bigquery.QueryParameterType{}
+	switch t := canonicalType(field.Type); t {
+	case "integer":
+		pt.Type = "INT64"
+	case "float":
+		pt.Type = "FLOAT64"
+	case "boolean":
+		pt.Type = "BOOL"
+	case "record":
+		pt.Type = "STRUCT"
+		for _, f := range field.Fields {
+			pt.StructTypes = append(pt.StructTypes, &bigquery.QueryParameterTypeStructTypes{
+				Name:        f.Name,
+				Type:        parameterType(f),
+				Description: f.Description,
+			})
+		}
+	case "range":
+		pt.Type = "RANGE"
+		if field.RangeElementType != nil {
+			pt.RangeElementType = &bigquery.QueryParameterType{Type: strings.ToUpper(field.RangeElementType.Type)}
+		}
+	default:
+		pt.Type = strings.ToUpper(t)
+	}
+
+	if isRepeated(field) {
+		return &bigquery.QueryParameterType{Type: "ARRAY", ArrayType: pt}
+	}
+	return pt
+}
+
+// parameterValue converts a value coerced by CoerceRow to the parameter value of field.
+func parameterValue(field *bigquery.TableFieldSchema, value bigquery.JsonValue) *bigquery.QueryParameterValue {
+	if value == nil {
+		return &bigquery.QueryParameterValue{NullFields: []string{"Value"}}
+	}
+
+	if values, ok := value.([]bigquery.JsonValue); ok && isRepeated(field) {
+		elem := *field
+		elem.Mode = "nullable"
+		pv := &bigquery.QueryParameterValue{ArrayValues: make([]*bigquery.QueryParameterValue, len(values))}
+		for i, v := range values {
+			pv.ArrayValues[i] = parameterValue(&elem, v)
+		}
+		return pv
+	}
+
+	if values, ok := value.(map[string]bigquery.JsonValue); ok {
+		pv := &bigquery.QueryParameterValue{StructValues: make(map[string]bigquery.QueryParameterValue, len(field.Fields))}
+		for _, f := range field.Fields {
+			pv.StructValues[f.Name] = *parameterValue(f, values[f.Name])
+		}
+		return pv
+	}
+
+	switch v := value.(type) {
+	case string:
+		return &bigquery.QueryParameterValue{Value: v}
+	case float64:
+		return &bigquery.QueryParameterValue{Value: strconv.FormatFloat(v, 'g', -1, 64)}
+	default:
+		return &bigquery.QueryParameterValue{Value: fmt.Sprint(v)}
+	}
+}
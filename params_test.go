@@ -0,0 +1,85 @@
+package bqschema
+
+import (
+	"time"
+
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+
+	"google.golang.org/api/bigquery/v2"
+)
+
+var _ = Describe("ToQueryParameters", func() {
+	type point struct {
+		Lat float64
+		Lng float64
+	}
+	type params struct {
+		ID     int64 `json:"id"`
+		Since  time.Time
+		Tags   []string
+		Near   point
+		Active *bool `json:"active,omitempty"`
+	}
+
+	It("should convert fields to named parameters", func() {
+		ps, err := ToQueryParameters(&params{
+			ID:    7,
+			Since: time.Date(2020, 1, 2, 3, 4, 5, 0, time.UTC),
+			Tags:  []string{"a", "b"},
+			Near:  point{Lat: 1.5, Lng: -2},
+		})
+		Expect(err).To(BeNil())
+		Expect(ps).To(Equal([]*bigquery.QueryParameter{
+			&bigquery.QueryParameter{
+				Name:           "id",
+				ParameterType:  &bigquery.QueryParameterType{Type: "INT64"},
+				ParameterValue: &bigquery.QueryParameterValue{Value: "7"},
+			},
+			&bigquery.QueryParameter{
+				Name:           "Since",
+				ParameterType:  &bigquery.QueryParameterType{Type: "TIMESTAMP"},
+				ParameterValue: &bigquery.QueryParameterValue{Value: "2020-01-02T03:04:05Z"},
+			},
+			&bigquery.QueryParameter{
+				Name:          "Tags",
+				ParameterType: &bigquery.QueryParameterType{Type: "ARRAY", ArrayType: &bigquery.QueryParameterType{Type: "STRING"}},
+				ParameterValue: &bigquery.QueryParameterValue{ArrayValues: []*bigquery.QueryParameterValue{
+					&bigquery.QueryParameterValue{Value: "a"},
+					&bigquery.QueryParameterValue{Value: "b"},
+				}},
+			},
+			&bigquery.QueryParameter{
+				Name: "Near",
+				ParameterType: &bigquery.QueryParameterType{Type: "STRUCT", StructTypes: []*bigquery.QueryParameterTypeStructTypes{
+					&bigquery.QueryParameterTypeStructTypes{Name: "Lat", Type: &bigquery.QueryParameterType{Type: "FLOAT64"}},
+					&bigquery.QueryParameterTypeStructTypes{Name: "Lng", Type: &bigquery.QueryParameterType{Type: "FLOAT64"}},
+				}},
+				ParameterValue: &bigquery.QueryParameterValue{StructValues: map[string]bigquery.QueryParameterValue{
+					"Lat": bigquery.QueryParameterValue{Value: "1.5"},
+					"Lng": bigquery.QueryParameterValue{Value: "-2"},
+				}},
+			},
+			&bigquery.QueryParameter{
+				Name:           "active",
+				ParameterType:  &bigquery.QueryParameterType{Type: "BOOL"},
+				ParameterValue: &bigquery.QueryParameterValue{NullFields: []string{"Value"}},
+			},
+		}))
+	})
+
+	It("should convert and encode fields with options", func() {
+		ps, err := ToQueryParameters(params{ID: 7, Near: point{Lat: 1.5, Lng: -2}}, WithFlatten(0, "_"))
+		Expect(err).To(BeNil())
+		Expect(ps[3]).To(Equal(&bigquery.QueryParameter{
+			Name:           "Near_Lat",
+			ParameterType:  &bigquery.QueryParameterType{Type: "FLOAT64"},
+			ParameterValue: &bigquery.QueryParameterValue{Value: "1.5"},
+		}))
+	})
+
+	It("should not convert values that are not structs", func() {
+		_, err := ToQueryParameters(1)
+		Expect(err).To(Equal(ErrNotStruct))
+	})
+})
@@ -0,0 +1,93 @@
+package bqschema
+
+import (
+	"fmt"
+	"strings"
+
+	"google.golang.org/api/bigquery/v2"
+)
+
+// GetField returns the field at a dotted path through nested records, such as
+// "order.items.price". The returned field belongs to schema; changes to it modify schema.
+func GetField(schema *bigquery.TableSchema, path string) (*bigquery.TableFieldSchema, error) {
+	return findField(schema.Fields, path)
+}
+
+// SetField replaces the field at a dotted path, or adds it to the end of its parent
+// record when no field exists at the path. The field is named after the last path element.
+func SetField(schema *bigquery.TableSchema, path string, field *bigquery.TableFieldSchema) error {
+	fields, name, err := parentFields(schema, path)
+	if err != nil {
+		return err
+	}
+
+	field.Name = name
+	for i, f := range *fields {
+		if strings.EqualFold(f.Name, name) {
+			(*fields)[i] = field
+			return nil
+		}
+	}
+	*fields = append(*fields, field)
+	return nil
+}
+
+// DeleteField removes the field at a dotted path.
+func DeleteField(schema *bigquery.TableSchema, path string) error {
+	fields, name, err := parentFields(schema, path)
+	if err != nil {
+		return err
+	}
+
+	for i, f := range *fields {
+		if strings.EqualFold(f.Name, name) {
+			*fields = append((*fields)[:i], (*fields)[i+1:]...)
+			return nil
+		}
+	}
+	return fmt.Errorf("%s: no such field", path)
+}
+
+// parentFields returns the fields of the record containing path and the last path element.
+func parentFields(schema *bigquery.TableSchema, path string) (*[]*bigquery.TableFieldSchema, string, error) {
+	i := strings.LastIndex(path, ".")
+	if i < 0 {
+		return &schema.Fields, path, nil
+	}
+
+	parent, err := findField(schema.Fields, path[:i])
+	if err != nil {
+		return nil, "", err
+	}
+	if canonicalType(parent.Type) != "record" {
+		return nil, "", fmt.Errorf("%s: not a record", path[:i])
+	}
+	return &parent.Fields, path[i+1:], nil
+}
+
+// findField resolves a dotted path through nested records, ignoring case like BigQuery does.
+func findField(fields []*bigquery.TableFieldSchema, path string) (*bigquery.TableFieldSchema, error) {
+	parts := strings.Split(path, ".")
+	var prefix string
+	for i, part := range parts {
+		var found *bigquery.TableFieldSchema
+		for _, field := range fields {
+			if strings.EqualFold(field.Name, part) {
+				found = field
+				break
+			}
+		}
+		if found == nil {
+			return nil, fmt.Errorf("%s%s: no such field", prefix, part)
+		}
+		if i == len(parts)-1 {
+			return found, nil
+		}
+		if canonicalType(found.Type) != "record" {
+			return nil, fmt.Errorf("%s%s: not a record", prefix, found.Name)
+		}
+		prefix += found.Name + "."
+		fields = found.Fields
+	}
+	return nil, nil
+}
@@ -0,0 +1,66 @@
+package bqschema
+
+import (
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+
+	"google.golang.org/api/bigquery/v2"
+)
+
+var _ = Describe("Field paths", func() {
+	type item struct {
+		SKU   string
+		Price float64
+	}
+	type order struct {
+		ID    int
+		Items []item
+	}
+	type customer struct {
+		Name  string
+		Order order
+	}
+
+	var schema *bigquery.TableSchema
+
+	BeforeEach(func() {
+		schema = MustToSchema(customer{})
+	})
+
+	It("should get fields through nested records", func() {
+		field, err := GetField(schema, "order.items.price")
+		Expect(err).To(BeNil())
+		Expect(field).To(Equal(&bigquery.TableFieldSchema{Mode: "required", Name: "Price", Type: "float"}))
+
+		_, err = GetField(schema, "Order.Total")
+		Expect(err).To(MatchError("Order.Total: no such field"))
+		_, err = GetField(schema, "Name.First")
+		Expect(err).To(MatchError("Name: not a record"))
+	})
+
+	It("should replace or add fields", func() {
+		Expect(SetField(schema, "Order.Items.Price", &bigquery.TableFieldSchema{Mode: "nullable", Type: "numeric"})).To(Succeed())
+		Expect(SetField(schema, "Order.Items.Quantity", &bigquery.TableFieldSchema{Mode: "nullable", Type: "integer"})).To(Succeed())
+		Expect(SetField(schema, "Email", &bigquery.TableFieldSchema{Mode: "nullable", Type: "string"})).To(Succeed())
+
+		Expect(schema.Fields[1].Fields[1].Fields).To(Equal([]*bigquery.TableFieldSchema{
+			&bigquery.TableFieldSchema{Mode: "required", Name: "SKU", Type: "string"},
+			&bigquery.TableFieldSchema{Mode: "nullable", Name: "Price", Type: "numeric"},
+			&bigquery.TableFieldSchema{Mode: "nullable", Name: "Quantity", Type: "integer"},
+		}))
+		Expect(schema.Fields[2]).To(Equal(&bigquery.TableFieldSchema{Mode: "nullable", Name: "Email", Type: "string"}))
+
+		Expect(SetField(schema, "Name.First", &bigquery.TableFieldSchema{})).To(MatchError("Name: not a record"))
+		Expect(SetField(schema, "Missing.First", &bigquery.TableFieldSchema{})).To(MatchError("Missing: no such field"))
+	})
+
+	It("should delete fields", func() {
+		Expect(DeleteField(schema, "order.items.sku")).To(Succeed())
+		Expect(DeleteField(schema, "Name")).To(Succeed())
+		Expect(schema.Fields).To(HaveLen(1))
+		Expect(schema.Fields[0].Fields[1].Fields).To(Equal([]*bigquery.TableFieldSchema{
+			&bigquery.TableFieldSchema{Mode: "required", Name: "Price", Type: "float"},
+		}))
+		Expect(DeleteField(schema, "Name")).To(MatchError("Name: no such field"))
+	})
+})
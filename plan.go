@@ -0,0 +1,230 @@
+package bqschema
+
+import (
+	"fmt"
+	"strings"
+
+	"google.golang.org/api/bigquery/v2"
+)
+
+// Operation is a single step migrating a table to a new schema. Statement is the
+// DDL performing it, empty for changes to nested fields which only the patch body
+// can make. Destructive operations lose data or require recreating the table.
+type Operation struct {
+	Path        string
+	Description string
+	Statement   string
+	Destructive bool
+}
+
+// Plan lists the operations migrating a table to the schema of a struct.
+// Patch is the schema to send with a tables.patch request applying every
+// operation that is not destructive.
+type Plan struct {
+	Operations []*Operation
+	Patch      *bigquery.TableSchema
+}
+
+// Destructive reports whether any operation of the plan is destructive.
+func (p *Plan) Destructive() bool {
+	for _, op := range p.Operations {
+		if op.Destructive {
+			return true
+		}
+	}
+	return false
+}
+
+// Statements returns the DDL statements of the plan's operations, in order.
+func (p *Plan) Statements() []string {
+	var statements []string
+	for _, op := range p.Operations {
+		if op.Statement != "" {
+			statements = append(statements, op.Statement)
+		}
+	}
+	return statements
+}
+
+// PlanMigration plans the operations migrating table, with the existing schema,
+// to the schema of src converted with opts. Table is the name used in DDL
+// statements, such as "project.dataset.table". As BigQuery only adds nullable
+// columns, the required fields of added records are added as nullable. A nil
+// existing schema fails with ErrNilSchema.
+func PlanMigration(table string, src interface{}, existing *bigquery.TableSchema, opts ...Option) (*Plan, error) {
+	if existing == nil {
+		return nil, ErrNilSchema
+	}
+	schema, err := ToSchema(src, opts...)
+	if err != nil {
+		return nil, err
+	}
+
+	d := Diff(existing, schema)
+	p := &Plan{Patch: &bigquery.TableSchema{Fields: copyFields(existing.Fields)}}
+	table = quoteIdent(table)
+
+	for _, c := range d.Added {
+		op := &Operation{Path: c.Path, Description: "add column"}
+		switch {
+		case canonicalMode(c.New.Mode) == "required":
+			op.Description = "add required column"
+			op.Destructive = true
+		case !strings.Contains(c.Path, "."):
+			op.Statement = fmt.Sprintf("ALTER TABLE %s ADD COLUMN %s %s", table, quoteIdent(c.Path), nullableType(c.New))
+			if c.New.DefaultValueExpression != "" {
+				op.Statement += " DEFAULT " + c.New.DefaultValueExpression
+			}
+		}
+		if !op.Destructive {
+			field := copyField(c.New)
+			relaxFields(field.Fields)
+			SetField(p.Patch, c.Path, field)
+		}
+		p.Operations = append(p.Operations, op)
+	}
+
+	for _, c := range d.Changed {
+		column := !strings.Contains(c.Path, ".")
+		field, _ := GetField(p.Patch, c.Path)
+
+		if c.TypeChanged() {
+			op := &Operation{
+				Path:        c.Path,
+				Description: fmt.Sprintf("change type from %s to %s", canonicalType(c.Old.Type), canonicalType(c.New.Type)),
+				Destructive: !widens(c.Old.Type, c.New.Type),
+			}
+			if !op.Destructive {
+				if column {
+					op.Statement = fmt.Sprintf("ALTER TABLE %s ALTER COLUMN %s SET DATA TYPE %s", table, quoteIdent(c.Path), nullableType(c.New))
+				}
+				field.Type = c.New.Type
+			}
+			p.Operations = append(p.Operations, op)
+		}
+
+		if c.ModeChanged() {
+			op := &Operation{
+				Path:        c.Path,
+				Description: fmt.Sprintf("change mode from %s to %s", canonicalMode(c.Old.Mode), canonicalMode(c.New.Mode)),
+				Destructive: canonicalMode(c.Old.Mode) != "required" || canonicalMode(c.New.Mode) != "nullable",
+			}
+			if !op.Destructive {
+				if column {
+					op.Statement = fmt.Sprintf("ALTER TABLE %s ALTER COLUMN %s DROP NOT NULL", table, quoteIdent(c.Path))
+				}
+				field.Mode = c.New.Mode
+			}
+			p.Operations = append(p.Operations, op)
+		}
+
+		if c.DescriptionChanged() {
+			op := &Operation{Path: c.Path, Description: "change description"}
+			if column {
+				op.Statement = fmt.Sprintf("ALTER TABLE %s ALTER COLUMN %s SET OPTIONS (description=%s)", table, quoteIdent(c.Path), quoteString(c.New.Description))
+			}
+			field.Description = c.New.Description
+			p.Operations = append(p.Operations, op)
+		}
+	}
+
+	for _, c := range d.Removed {
+		op := &Operation{Path: c.Path, Description: "drop column", Destructive: true}
+		if !strings.Contains(c.Path, ".") {
+			op.Statement = fmt.Sprintf("ALTER TABLE %s DROP COLUMN %s", table, quoteIdent(c.Path))
+		}
+		p.Operations = append(p.Operations, op)
+	}
+	return p, nil
+}
+
+// widens reports whether BigQuery can change a column's type in place without losing data.
+func widens(from, to string) bool {
+	switch canonicalType(from) {
+	case "integer":
+		switch canonicalType(to) {
+		case "numeric", "bignumeric", "float":
+			return true
+		}
+	case "numeric":
+		switch canonicalType(to) {
+		case "bignumeric", "float":
+			return true
+		}
+	}
+	return false
+}
+
+// nullableType returns the standard SQL type of a column without the NOT NULL
+// constraints, at any level, that ALTER TABLE statements reject.
+func nullableType(field *bigquery.TableFieldSchema) string {
+	return sqlType(field, nullableType)
+}
+
+// sqlType returns the standard SQL type of field, arrays included, writing the
+// type of each field of a struct with fieldType.
+func sqlType(field *bigquery.TableFieldSchema, fieldType func(*bigquery.TableFieldSchema) string) string {
+	var t string
+	switch canonicalType(field.Type) {
+	case "integer":
+		t = "INT64"
+	case "float":
+		t = "FLOAT64"
+	case "boolean":
+		t = "BOOL"
+	case "record":
+		fields := make([]string, len(field.Fields))
+		for i, f := range field.Fields {
+			fields[i] = quoteIdent(f.Name) + " " + fieldType(f)
+		}
+		t = "STRUCT<" + strings.Join(fields, ", ") + ">"
+	case "range":
+		t = "RANGE"
+		if field.RangeElementType != nil {
+			t += "<" + strings.ToUpper(field.RangeElementType.Type) + ">"
+		}
+	default:
+		t = strings.ToUpper(field.Type)
+	}
+
+	if isRepeated(field) {
+		return "ARRAY<" + t + ">"
+	}
+	return t
+}
+
+// quoteIdent quotes a GoogleSQL identifier, such as a column or table name.
+func quoteIdent(name string) string {
+	return quote(name, '`')
+}
+
+// quoteString quotes a GoogleSQL string literal.
+func quoteString(s string) string {
+	return quote(s, '"')
+}
+
+// quote encloses s in the quote character q, escaping q, backslashes and
+// control characters as GoogleSQL string literals and quoted identifiers do.
+func quote(s string, q rune) string {
+	var b strings.Builder
+	b.WriteRune(q)
+	for _, r := range s {
+		switch {
+		case r == q || r == '\\':
+			b.WriteRune('\\')
+			b.WriteRune(r)
+		case r == '\n':
+			b.WriteString(`\n`)
+		case r == '\r':
+			b.WriteString(`\r`)
+		case r == '\t':
+			b.WriteString(`\t`)
+		case r < ' ' || r == 0x7f:
+			fmt.Fprintf(&b, `\x%02x`, r)
+		default:
+			b.WriteRune(r)
+		}
+	}
+	b.WriteRune(q)
+	return b.String()
+}
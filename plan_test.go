@@ -0,0 +1,150 @@
+package bqschema
+
+import (
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+
+	"google.golang.org/api/bigquery/v2"
+)
+
+var _ = Describe("PlanMigration", func() {
+	type address struct {
+		City string `json:"city,omitempty"`
+		Zip  string `json:"zip,omitempty"`
+	}
+	type user struct {
+		ID      int      `json:"id"`
+		Score   float64  `json:"score,omitempty"`
+		Tags    []string `json:"tags"`
+		Address address  `json:"address"`
+		Name    string   `json:"name"`
+	}
+
+	existing := &bigquery.TableSchema{
+		Fields: []*bigquery.TableFieldSchema{
+			&bigquery.TableFieldSchema{Mode: "REQUIRED", Name: "id", Type: "INTEGER"},
+			&bigquery.TableFieldSchema{Mode: "REQUIRED", Name: "score", Type: "INTEGER"},
+			&bigquery.TableFieldSchema{
+				Mode: "NULLABLE",
+				Name: "address",
+				Type: "RECORD",
+				Fields: []*bigquery.TableFieldSchema{
+					&bigquery.TableFieldSchema{Mode: "NULLABLE", Name: "city", Type: "STRING"},
+				},
+			},
+			&bigquery.TableFieldSchema{Mode: "NULLABLE", Name: "legacy", Type: "STRING"},
+			&bigquery.TableFieldSchema{Mode: "NULLABLE", Name: "name", Type: "STRING"},
+		},
+	}
+
+	It("should plan the operations migrating the table", func() {
+		p, err := PlanMigration("p.d.users", user{}, existing)
+		Expect(err).To(BeNil())
+
+		Expect(p.Statements()).To(Equal([]string{
+			"ALTER TABLE `p.d.users` ADD COLUMN `tags` ARRAY<STRING>",
+			"ALTER TABLE `p.d.users` ALTER COLUMN `score` SET DATA TYPE FLOAT64",
+			"ALTER TABLE `p.d.users` ALTER COLUMN `score` DROP NOT NULL",
+			"ALTER TABLE `p.d.users` DROP COLUMN `legacy`",
+		}))
+		Expect(p.Destructive()).To(BeTrue())
+
+		descriptions := map[string]string{}
+		destructive := map[string]bool{}
+		for _, op := range p.Operations {
+			descriptions[op.Path] += op.Description + ";"
+			destructive[op.Path] = destructive[op.Path] || op.Destructive
+		}
+		Expect(descriptions).To(Equal(map[string]string{
+			"tags":        "add column;",
+			"address.zip": "add column;",
+			"score":       "change type from integer to float;change mode from required to nullable;",
+			"name":        "change mode from nullable to required;",
+			"legacy":      "drop column;",
+		}))
+		Expect(destructive).To(Equal(map[string]bool{
+			"tags":        false,
+			"address.zip": false,
+			"score":       false,
+			"name":        true,
+			"legacy":      true,
+		}))
+	})
+
+	It("should patch the existing schema with every operation that is not destructive", func() {
+		p, err := PlanMigration("t", user{}, existing)
+		Expect(err).To(BeNil())
+		Expect(p.Patch.Fields).To(Equal([]*bigquery.TableFieldSchema{
+			&bigquery.TableFieldSchema{Mode: "REQUIRED", Name: "id", Type: "INTEGER"},
+			&bigquery.TableFieldSchema{Mode: "nullable", Name: "score", Type: "float"},
+			&bigquery.TableFieldSchema{
+				Mode: "NULLABLE",
+				Name: "address",
+				Type: "RECORD",
+				Fields: []*bigquery.TableFieldSchema{
+					&bigquery.TableFieldSchema{Mode: "NULLABLE", Name: "city", Type: "STRING"},
+					&bigquery.TableFieldSchema{Mode: "nullable", Name: "zip", Type: "string"},
+				},
+			},
+			&bigquery.TableFieldSchema{Mode: "NULLABLE", Name: "legacy", Type: "STRING"},
+			&bigquery.TableFieldSchema{Mode: "NULLABLE", Name: "name", Type: "STRING"},
+			&bigquery.TableFieldSchema{Mode: "repeated", Name: "tags", Type: "string"},
+		}))
+		Expect(existing.Fields).To(HaveLen(5))
+	})
+
+	It("should add records with nullable fields", func() {
+		type place struct {
+			ID      int     `json:"id"`
+			Address address `json:"address"`
+			Home    struct {
+				Lat float64 `json:"lat"`
+			} `json:"home"`
+		}
+		p, err := PlanMigration("t", place{}, &bigquery.TableSchema{Fields: []*bigquery.TableFieldSchema{
+			&bigquery.TableFieldSchema{Mode: "REQUIRED", Name: "id", Type: "INTEGER"},
+			&bigquery.TableFieldSchema{Mode: "NULLABLE", Name: "address", Type: "RECORD", Fields: []*bigquery.TableFieldSchema{
+				&bigquery.TableFieldSchema{Mode: "NULLABLE", Name: "city", Type: "STRING"},
+				&bigquery.TableFieldSchema{Mode: "NULLABLE", Name: "zip", Type: "STRING"},
+			}},
+		}})
+		Expect(err).To(BeNil())
+		Expect(p.Statements()).To(Equal([]string{"ALTER TABLE `t` ADD COLUMN `home` STRUCT<`lat` FLOAT64>"}))
+		Expect(p.Patch.Fields[2]).To(Equal(&bigquery.TableFieldSchema{Mode: "nullable", Name: "home", Type: "record", Fields: []*bigquery.TableFieldSchema{
+			&bigquery.TableFieldSchema{Mode: "nullable", Name: "lat", Type: "float"},
+		}}))
+	})
+
+	It("should escape descriptions and identifiers as GoogleSQL does", func() {
+		type row struct {
+			Name string `json:"na\\me" description:"The \"display\" name,\nin C:\\Users."`
+		}
+		p, err := PlanMigration("p.d.t`x", row{}, &bigquery.TableSchema{Fields: []*bigquery.TableFieldSchema{
+			&bigquery.TableFieldSchema{Mode: "REQUIRED", Name: "na\\me", Type: "STRING"},
+		}})
+		Expect(err).To(BeNil())
+		Expect(p.Statements()).To(Equal([]string{
+			"ALTER TABLE `p.d.t\\`x` ALTER COLUMN `na\\\\me` SET OPTIONS (description=\"The \\\"display\\\" name,\\nin C:\\\\Users.\")",
+		}))
+	})
+
+	It("should convert the struct with options", func() {
+		p, err := PlanMigration("t", user{}, existing, WithDefaultMode(Nullable))
+		Expect(err).To(BeNil())
+		for _, op := range p.Operations {
+			Expect(op.Path).NotTo(Equal("name"))
+		}
+	})
+
+	It("should plan nothing for an up to date table", func() {
+		p, err := PlanMigration("t", user{}, MustToSchema(user{}))
+		Expect(err).To(BeNil())
+		Expect(p.Operations).To(BeEmpty())
+		Expect(p.Destructive()).To(BeFalse())
+	})
+
+	It("should not plan migrations from a nil schema", func() {
+		_, err := PlanMigration("t", user{}, nil)
+		Expect(err).To(Equal(ErrNilSchema))
+	})
+})
@@ -0,0 +1,93 @@
+package bqschema
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+
+	"google.golang.org/api/bigquery/v2"
+)
+
+// selection maps lower cased field names to the selected fields.
+type selection map[string]*selected
+
+// selected is a field chosen by a path; a nil fields selects the whole field.
+type selected struct {
+	name   string
+	fields selection
+}
+
+func (s selection) add(parts []string) {
+	key := strings.ToLower(parts[0])
+	sel, ok := s[key]
+	if !ok {
+		sel = &selected{name: parts[0], fields: selection{}}
+		s[key] = sel
+	}
+	if len(parts) == 1 {
+		sel.fields = nil
+		return
+	}
+	if ok && sel.fields == nil {
+		return
+	}
+	sel.fields.add(parts[1:])
+}
+
+// Project returns the subset of schema selected by fieldPaths. Paths are dotted
+// to select fields inside records, e.g. "address.city"; selecting a record selects
+// all of its fields. Fields keep their order in schema.
+func Project(schema *bigquery.TableSchema, fieldPaths ...string) (*bigquery.TableSchema, error) {
+	sel := selection{}
+	for _, path := range fieldPaths {
+		sel.add(strings.Split(path, "."))
+	}
+
+	fields, err := projectFields(schema.Fields, sel, "")
+	if err != nil {
+		return nil, err
+	}
+	return &bigquery.TableSchema{Fields: fields}, nil
+}
+
+func projectFields(fields []*bigquery.TableFieldSchema, sel selection, prefix string) ([]*bigquery.TableFieldSchema, error) {
+	projected := make([]*bigquery.TableFieldSchema, 0, len(sel))
+	found := map[string]bool{}
+	for _, field := range fields {
+		key := strings.ToLower(field.Name)
+		sub, ok := sel[key]
+		if !ok {
+			continue
+		}
+		found[key] = true
+
+		if sub.fields == nil {
+			projected = append(projected, copyField(field))
+			continue
+		}
+
+		path := prefix + field.Name
+		if canonicalType(field.Type) != "record" {
+			return nil, fmt.Errorf("%s: not a record", path)
+		}
+		subFields, err := projectFields(field.Fields, sub.fields, path+".")
+		if err != nil {
+			return nil, err
+		}
+		c := copyField(field)
+		c.Fields = subFields
+		projected = append(projected, c)
+	}
+
+	var missing []string
+	for key, sub := range sel {
+		if !found[key] {
+			missing = append(missing, sub.name)
+		}
+	}
+	if len(missing) > 0 {
+		sort.Strings(missing)
+		return nil, fmt.Errorf("%s%s: no such field", prefix, missing[0])
+	}
+	return projected, nil
+}
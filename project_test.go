@@ -0,0 +1,63 @@
+package bqschema
+
+import (
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+
+	"google.golang.org/api/bigquery/v2"
+)
+
+var _ = Describe("Project", func() {
+	type address struct {
+		City    string
+		Country string
+	}
+	type user struct {
+		ID      int
+		Name    string
+		Address address
+		Orders  []struct {
+			ID    int
+			Price float64
+		}
+	}
+	schema := MustToSchema(user{})
+
+	It("should select top level fields in schema order", func() {
+		projected, err := Project(schema, "name", "ID")
+		Expect(err).To(BeNil())
+		Expect(projected.Fields).To(Equal([]*bigquery.TableFieldSchema{
+			&bigquery.TableFieldSchema{Mode: "required", Name: "ID", Type: "integer"},
+			&bigquery.TableFieldSchema{Mode: "required", Name: "Name", Type: "string"},
+		}))
+	})
+
+	It("should select fields inside records with dotted paths", func() {
+		projected, err := Project(schema, "Address.City", "Orders.Price", "Orders")
+		Expect(err).To(BeNil())
+		Expect(projected.Fields).To(Equal([]*bigquery.TableFieldSchema{
+			&bigquery.TableFieldSchema{
+				Mode: "nullable",
+				Name: "Address",
+				Type: "record",
+				Fields: []*bigquery.TableFieldSchema{
+					&bigquery.TableFieldSchema{Mode: "required", Name: "City", Type: "string"},
+				},
+			},
+			schema.Fields[3],
+		}))
+	})
+
+	It("should not share fields with the projected schema", func() {
+		projected, _ := Project(schema, "ID")
+		projected.Fields[0].Name = "changed"
+		Expect(schema.Fields[0].Name).To(Equal("ID"))
+	})
+
+	It("should error on paths that do not exist", func() {
+		_, err := Project(schema, "Address.Street")
+		Expect(err).To(MatchError("Address.Street: no such field"))
+		_, err = Project(schema, "Name.First")
+		Expect(err).To(MatchError("Name: not a record"))
+	})
+})
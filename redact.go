@@ -0,0 +1,83 @@
+package bqschema
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+	"reflect"
+	"strconv"
+	"strings"
+	"time"
+
+	"google.golang.org/api/bigquery/v2"
+)
+
+// applyRedaction checks the redaction declared by a field's redact tag and
+// annotates its column. Columns redacted with sha256 hold the hex encoded
+// SHA-256 digest of each value and so are strings; truncate=n keeps the first
+// n characters of string values.
+func applyRedaction(tfs *bigquery.TableFieldSchema, redact string) error {
+	if canonicalType(tfs.Type) == "record" {
+		return fieldError(KindTag, "redaction not allowed for record column")
+	}
+	switch {
+	case redact == "sha256":
+		tfs.Type = "string"
+		tfs.RangeElementType = nil
+	case strings.HasPrefix(redact, "truncate="):
+		if n, err := strconv.Atoi(strings.TrimPrefix(redact, "truncate=")); err != nil || n < 0 {
+			return fieldError(KindTag, "invalid redaction %q", redact)
+		}
+		if t := canonicalType(tfs.Type); t != "string" {
+			return fieldError(KindTag, "truncation not allowed for %s column", t)
+		}
+	default:
+		return fieldError(KindTag, "invalid redaction %q", redact)
+	}
+	return nil
+}
+
+// redactionNote is appended to the description of redacted columns.
+func redactionNote(redact string) string {
+	return fmt.Sprintf("Redacted: %s.", redact)
+}
+
+// redactValue redacts a value converted by rowValue, element by element for repeated fields.
+func redactValue(redact string, v interface{}) interface{} {
+	if v == nil {
+		return nil
+	}
+	if rv := reflect.ValueOf(v); rv.Kind() == reflect.Slice && rv.Type().Elem().Kind() != reflect.Uint8 {
+		values := make([]interface{}, rv.Len())
+		for i := range values {
+			values[i] = redactValue(redact, rv.Index(i).Interface())
+		}
+		return values
+	}
+
+	s := redactString(v)
+	if redact == "sha256" {
+		sum := sha256.Sum256([]byte(s))
+		return hex.EncodeToString(sum[:])
+	}
+	n, _ := strconv.Atoi(strings.TrimPrefix(redact, "truncate="))
+	if r := []rune(s); len(r) > n {
+		return string(r[:n])
+	}
+	return s
+}
+
+func redactString(v interface{}) string {
+	switch v := v.(type) {
+	case string:
+		return v
+	case []byte:
+		return string(v)
+	case time.Time:
+		return v.UTC().Format(time.RFC3339Nano)
+	case fmt.Stringer:
+		return v.String()
+	default:
+		return fmt.Sprint(v)
+	}
+}
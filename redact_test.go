@@ -0,0 +1,63 @@
+package bqschema
+
+import (
+	"reflect"
+
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+
+	"google.golang.org/api/bigquery/v2"
+)
+
+var _ = Describe("Redaction", func() {
+	type contact struct {
+		Email  string   `json:"email" redact:"sha256" description:"Contact email."`
+		Phone  string   `json:"phone" redact:"truncate=4"`
+		IDs    []int    `json:"ids" redact:"sha256"`
+		Note   *string  `json:"note,omitempty" redact:"sha256"`
+		Titles []string `json:"titles" redact:"truncate=2"`
+	}
+
+	It("should annotate redacted columns", func() {
+		schema, err := ToSchema(contact{})
+		Expect(err).To(BeNil())
+		Expect(schema.Fields).To(Equal([]*bigquery.TableFieldSchema{
+			&bigquery.TableFieldSchema{Mode: "required", Name: "email", Type: "string", Description: "Contact email. Redacted: sha256."},
+			&bigquery.TableFieldSchema{Mode: "required", Name: "phone", Type: "string", Description: "Redacted: truncate=4."},
+			&bigquery.TableFieldSchema{Mode: "repeated", Name: "ids", Type: "string", Description: "Redacted: sha256."},
+			&bigquery.TableFieldSchema{Mode: "nullable", Name: "note", Type: "string", Description: "Redacted: sha256."},
+			&bigquery.TableFieldSchema{Mode: "repeated", Name: "titles", Type: "string", Description: "Redacted: truncate=2."},
+		}))
+	})
+
+	It("should redact values when encoding rows", func() {
+		c := contact{Email: "a@example.com", Phone: "5551234", IDs: []int{1}, Titles: []string{"Dr", "Prof"}}
+		row, err := structToRow(reflect.ValueOf(c))
+		Expect(err).To(BeNil())
+		Expect(row).To(Equal(map[string]interface{}{
+			"email":  "08168cd80dfd534ab0f10af10f1303fe00af2d43ab5c1432360d137f8197e17a",
+			"phone":  "5551",
+			"ids":    []interface{}{"6b86b273ff34fce19d6b804eff5a3f5747ada4eaa22f1d49c01e52ddb7875b4b"},
+			"note":   nil,
+			"titles": []interface{}{"Dr", "Pr"},
+		}))
+
+		_, err = CoerceRow(MustToSchema(contact{}), row)
+		Expect(err).To(BeNil())
+	})
+
+	It("should reject invalid redactions", func() {
+		_, err := ToSchema(struct {
+			A string `redact:"md5"`
+		}{})
+		Expect(err).To(MatchError(`A: invalid redaction "md5"`))
+		_, err = ToSchema(struct {
+			A int `redact:"truncate=2"`
+		}{})
+		Expect(err).To(MatchError("A: truncation not allowed for integer column"))
+		_, err = ToSchema(struct {
+			A struct{ B string } `redact:"sha256"`
+		}{})
+		Expect(err).To(MatchError("A: redaction not allowed for record column"))
+	})
+})
@@ -0,0 +1,225 @@
+package bqschema
+
+import (
+	"reflect"
+	"sort"
+	"sync"
+
+	"google.golang.org/api/bigquery/v2"
+)
+
+// The registry holds what the Register functions declare for the whole
+// process. Every registration increments registryGen; Converters and type plans
+// record the generation they were built with and rebuild once it changes, so a
+// registration applies to every later conversion, those of existing Converters
+// included.
+var (
+	registryMu    sync.RWMutex
+	typeMappings  = map[reflect.Type]*bigquery.TableFieldSchema{}
+	concreteTypes = map[string]reflect.Type{}
+	stringTypes   []func(reflect.Type) bool
+	registryGen   int
+
+	// jsonOptions tells, for each json tag option known to ToSchema, whether it makes a column nullable.
+	jsonOptions = map[string]bool{"omitempty": true, "omitzero": true, "string": false}
+)
+
+// RegisterTypeMapping declares the column every field of type t converts to, such as
+// a NUMERIC with a precision and scale for a money type. The field's Name is ignored;
+// an empty Mode is replaced by the mode the field would otherwise have.
+// Implement SchemaMarshaler and SchemaUnmarshaler on t to encode its values
+// into rows and decode them from query results.
+// Use WithTypeMapping to declare a mapping for a single conversion only.
+func RegisterTypeMapping(t reflect.Type, field *bigquery.TableFieldSchema) {
+	registryMu.Lock()
+	defer registryMu.Unlock()
+	typeMappings[t] = copyField(field)
+	registryGen++
+}
+
+func registryGeneration() int {
+	registryMu.RLock()
+	defer registryMu.RUnlock()
+	return registryGen
+}
+
+// WithTypeMapping is like RegisterTypeMapping, scoped to the Converter or ToSchema call it is passed to.
+func WithTypeMapping(t reflect.Type, field *bigquery.TableFieldSchema) Option {
+	return func(o *options) {
+		if o.typeMappings == nil {
+			o.typeMappings = map[reflect.Type]*bigquery.TableFieldSchema{}
+		}
+		o.typeMappings[t] = copyField(field)
+	}
+}
+
+func (o *options) typeMapping(t reflect.Type) (*bigquery.TableFieldSchema, bool) {
+	if field, ok := o.typeMappings[t]; ok {
+		return field, true
+	}
+	registryMu.RLock()
+	defer registryMu.RUnlock()
+	field, ok := typeMappings[t]
+	return field, ok
+}
+
+func mappedField(mapped *bigquery.TableFieldSchema, name, mode string) *bigquery.TableFieldSchema {
+	tfs := copyField(mapped)
+	tfs.Name = name
+	if tfs.Mode == "" || mode == "repeated" {
+		tfs.Mode = mode
+	}
+	return tfs
+}
+
+// RegisterConcreteType names the type of sample so interface fields tagged
+// `bigquery:",as=name"` convert as if they held a value of that type.
+// Use WithConcreteType to name a type for a single conversion only.
+func RegisterConcreteType(name string, sample interface{}) {
+	registryMu.Lock()
+	defer registryMu.Unlock()
+	concreteTypes[name] = reflect.TypeOf(sample)
+	registryGen++
+}
+
+// WithConcreteType is like RegisterConcreteType, scoped to the Converter or ToSchema call it is passed to.
+func WithConcreteType(name string, sample interface{}) Option {
+	return func(o *options) {
+		if o.concreteTypes == nil {
+			o.concreteTypes = map[string]reflect.Type{}
+		}
+		o.concreteTypes[name] = reflect.TypeOf(sample)
+	}
+}
+
+// concreteType returns the type declaring the schema of an interface field.
+func (o *options) concreteType(tag fieldTag, sf reflect.StructField) (reflect.Type, error) {
+	name, ok := tag.attrs["as"]
+	if !ok {
+		return nil, &ErrInconvertibleType{sf.Type.String()}
+	}
+	if t, ok := o.concreteTypes[name]; ok {
+		return t, nil
+	}
+	registryMu.RLock()
+	defer registryMu.RUnlock()
+	if t, ok := concreteTypes[name]; ok {
+		return t, nil
+	}
+	return nil, fieldError(KindTag, "unknown concrete type %q", name)
+}
+
+// RegisterStringType declares the types accepted by pred, which implement
+// fmt.Stringer themselves or through a pointer, as holding identifiers: fields
+// of those types convert to string columns and their values encode with
+// String(). Named types of scalar kinds, such as type UserID int64, can be
+// declared too, to force STRING columns for identifiers that are numbers in
+// Go; their values without a String method encode as their underlying value.
+// Types with an Encode() string method, such as datastore keys, are string
+// types without registering.
+// Use WithStringType to declare string types for a single conversion only.
+func RegisterStringType(pred func(t reflect.Type) bool) {
+	registryMu.Lock()
+	defer registryMu.Unlock()
+	stringTypes = append(stringTypes, pred)
+	registryGen++
+}
+
+// WithStringType is like RegisterStringType, scoped to the Converter or ToSchema call it is passed to.
+func WithStringType(pred func(t reflect.Type) bool) Option {
+	return func(o *options) {
+		o.stringTypes = append(o.stringTypes, pred)
+	}
+}
+
+// stringType reports whether fields of type t convert to string columns.
+// A nil options checks only the registered string types.
+func (o *options) stringType(t reflect.Type) bool {
+	if !t.Implements(stringerType) && !reflect.PtrTo(t).Implements(stringerType) && !namedScalar(t) {
+		return false
+	}
+	if encodesKey(t) {
+		return true
+	}
+	if o != nil {
+		for _, pred := range o.stringTypes {
+			if pred(t) {
+				return true
+			}
+		}
+	}
+	registryMu.RLock()
+	defer registryMu.RUnlock()
+	for _, pred := range stringTypes {
+		if pred(t) {
+			return true
+		}
+	}
+	return false
+}
+
+// namedScalar reports whether t is a named type of a simple kind, declared by a package.
+func namedScalar(t reflect.Type) bool {
+	_, isSimple := simpleType(t.Kind())
+	return isSimple && t.PkgPath() != ""
+}
+
+// encodesKey reports whether t has an Encode() string method, like the keys of the datastore packages.
+func encodesKey(t reflect.Type) bool {
+	m, ok := reflect.PtrTo(t).MethodByName("Encode")
+	return ok && m.Type.NumIn() == 1 && m.Type.NumOut() == 1 && m.Type.Out(0).Kind() == reflect.String
+}
+
+// defaultTagPriority is the order tags name columns in: the bigquery tag
+// overrides the json tag.
+var defaultTagPriority = []string{"bigquery", "json"}
+
+// WithTagPriority declares the struct tags naming columns, and giving their
+// options, in decreasing priority, so types already tagged for other packages
+// need no json tags repeating their names:
+//
+//	bqschema.ToSchema(user{}, bqschema.WithTagPriority("bigquery", "db", "json"))
+//
+// Only the listed tags are read. A field is named by the first tag naming it
+// and skipped by a tag saying "-" unless a tag before it names the field. Tags
+// other than bigquery, json and gorm name columns with their first comma
+// separated element or a name attribute. Pass the same option to the functions
+// encoding rows and decoding query results of the type, such as InsertStructs
+// and RowsToStructs, for their columns to match.
+func WithTagPriority(tags ...string) Option {
+	return func(o *options) {
+		o.tagPriority = append([]string(nil), tags...)
+	}
+}
+
+// RegisterJSONOption declares a json tag option, such as one added to
+// encoding/json after this package, and whether fields tagged with it convert
+// to nullable columns. Fields tagged with undeclared options are reported, and
+// fail to convert in strict mode, rather than silently converted as if the
+// option were absent.
+func RegisterJSONOption(option string, nullable bool) {
+	registryMu.Lock()
+	defer registryMu.Unlock()
+	jsonOptions[option] = nullable
+	registryGen++
+}
+
+// jsonOption returns whether a json tag option makes a column nullable, and whether the option is known.
+func jsonOption(option string) (nullable, ok bool) {
+	registryMu.RLock()
+	defer registryMu.RUnlock()
+	nullable, ok = jsonOptions[option]
+	return nullable, ok
+}
+
+// knownJSONOptions returns the declared json tag options, sorted.
+func knownJSONOptions() []string {
+	registryMu.RLock()
+	defer registryMu.RUnlock()
+	options := make([]string, 0, len(jsonOptions))
+	for option := range jsonOptions {
+		options = append(options, option)
+	}
+	sort.Strings(options)
+	return options
+}
@@ -0,0 +1,288 @@
+package bqschema
+
+import (
+	"bytes"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"math"
+	"reflect"
+	"strconv"
+
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+
+	"google.golang.org/api/bigquery/v2"
+)
+
+type registryMoney struct {
+	Units int64
+	Nanos int32
+}
+
+// MarshalBigQuery encodes the amount as a NUMERIC string.
+func (m registryMoney) MarshalBigQuery() (interface{}, error) {
+	if m.Nanos < 0 || m.Nanos >= 1e9 {
+		return nil, fmt.Errorf("nanos %d out of range", m.Nanos)
+	}
+	return strconv.FormatFloat(float64(m.Units)+float64(m.Nanos)/1e9, 'f', -1, 64), nil
+}
+
+func (m *registryMoney) UnmarshalBigQuery(value interface{}) error {
+	f, err := strconv.ParseFloat(value.(string), 64)
+	units := math.Floor(f)
+	*m = registryMoney{Units: int64(units), Nanos: int32(math.Round((f - units) * 1e9))}
+	return err
+}
+
+type registryID int64
+
+var _ = Describe("Type mappings", func() {
+	type row struct {
+		Price  registryMoney
+		Prices []*registryMoney
+		Tax    *registryMoney `json:"tax,omitempty"`
+		ID     registryID
+	}
+
+	numeric := &bigquery.TableFieldSchema{Type: "numeric", Precision: 38, Scale: 9}
+
+	BeforeEach(func() {
+		RegisterTypeMapping(reflect.TypeOf(registryMoney{}), numeric)
+	})
+
+	AfterEach(func() {
+		registryMu.Lock()
+		delete(typeMappings, reflect.TypeOf(registryMoney{}))
+		registryGen++
+		registryMu.Unlock()
+	})
+
+	It("should convert fields of registered types to the declared column", func() {
+		schema, err := ToSchema(row{})
+		Expect(err).To(BeNil())
+		Expect(schema.Fields).To(Equal([]*bigquery.TableFieldSchema{
+			&bigquery.TableFieldSchema{Mode: "required", Name: "Price", Type: "numeric", Precision: 38, Scale: 9},
+			&bigquery.TableFieldSchema{Mode: "repeated", Name: "Prices", Type: "numeric", Precision: 38, Scale: 9},
+			&bigquery.TableFieldSchema{Mode: "nullable", Name: "tax", Type: "numeric", Precision: 38, Scale: 9},
+			&bigquery.TableFieldSchema{Mode: "required", Name: "ID", Type: "integer"},
+		}))
+	})
+
+	It("should prefer scoped mappings over registered ones", func() {
+		schema, err := ToSchema(row{},
+			WithTypeMapping(reflect.TypeOf(registryMoney{}), &bigquery.TableFieldSchema{Mode: "nullable", Type: "bignumeric"}),
+			WithTypeMapping(reflect.TypeOf(registryID(0)), &bigquery.TableFieldSchema{Type: "string"}),
+		)
+		Expect(err).To(BeNil())
+		Expect(schema.Fields[0]).To(Equal(&bigquery.TableFieldSchema{Mode: "nullable", Name: "Price", Type: "bignumeric"}))
+		Expect(schema.Fields[1]).To(Equal(&bigquery.TableFieldSchema{Mode: "repeated", Name: "Prices", Type: "bignumeric"}))
+		Expect(schema.Fields[3]).To(Equal(&bigquery.TableFieldSchema{Mode: "required", Name: "ID", Type: "string"}))
+
+		Expect(MustToSchema(row{}).Fields[3].Type).To(Equal("integer"))
+	})
+
+	It("should encode and decode values of mapped types with their marshalers", func() {
+		type priced struct {
+			Price  registryMoney    `json:"price"`
+			Prices []*registryMoney `json:"prices"`
+			Tax    *registryMoney   `json:"tax,omitempty"`
+		}
+		in := priced{Price: registryMoney{12, 340000000}, Prices: []*registryMoney{{1, 500000000}, nil}}
+		schema := MustToSchema(priced{})
+
+		var buf bytes.Buffer
+		Expect(NewNDJSONWriter(&buf, schema).Write(in)).To(Succeed())
+		Expect(buf.String()).To(Equal(`{"price":"12.34","prices":["1.5"]}` + "\n"))
+
+		params, err := ToQueryParameters(in)
+		Expect(err).To(BeNil())
+		Expect(params[0].ParameterValue.Value).To(Equal("12.34"))
+		Expect(params[1].ParameterValue.ArrayValues[0].Value).To(Equal("1.5"))
+
+		var line map[string]interface{}
+		Expect(json.Unmarshal(buf.Bytes(), &line)).To(Succeed())
+		row := &bigquery.TableRow{F: []*bigquery.TableCell{
+			{V: line["price"]},
+			{V: []interface{}{map[string]interface{}{"v": line["prices"].([]interface{})[0]}}},
+			{V: line["tax"]},
+		}}
+		var out []priced
+		Expect(RowsToStructs(schema, []*bigquery.TableRow{row}, &out)).To(Succeed())
+		Expect(out).To(Equal([]priced{{Price: in.Price, Prices: []*registryMoney{in.Prices[0]}}}))
+	})
+
+	It("should report the errors of marshalers with the path of the column", func() {
+		_, err := ToQueryParameters(struct {
+			Prices []registryMoney `json:"prices"`
+		}{Prices: []registryMoney{{Nanos: -1}}})
+		Expect(err).To(MatchError("prices: nanos -1 out of range"))
+		Expect(errors.Is(err, &FieldError{Path: "prices", Kind: KindEncode})).To(BeTrue())
+	})
+
+	It("should not be affected by changes to the registered field", func() {
+		numeric.Scale = 2
+		defer func() { numeric.Scale = 9 }()
+		Expect(MustToSchema(row{}).Fields[0].Scale).To(BeNumerically("==", 9))
+	})
+})
+
+type registryShape interface {
+	Area() float64
+}
+
+type registryCircle struct {
+	Radius float64
+}
+
+func (c registryCircle) Area() float64 { return 3 * c.Radius * c.Radius }
+
+var _ = Describe("Concrete types", func() {
+	type row struct {
+		Shape  registryShape   `bigquery:"shape,as=circle"`
+		Shapes []registryShape `bigquery:",as=circle"`
+	}
+
+	circle := &bigquery.TableFieldSchema{
+		Type: "record",
+		Fields: []*bigquery.TableFieldSchema{
+			&bigquery.TableFieldSchema{Mode: "required", Name: "Radius", Type: "float"},
+		},
+	}
+
+	It("should convert interface fields as their declared concrete type", func() {
+		schema, err := ToSchema(row{}, WithConcreteType("circle", &registryCircle{}))
+		Expect(err).To(BeNil())
+		Expect(schema.Fields).To(Equal([]*bigquery.TableFieldSchema{
+			&bigquery.TableFieldSchema{Mode: "nullable", Name: "shape", Type: circle.Type, Fields: circle.Fields},
+			&bigquery.TableFieldSchema{Mode: "repeated", Name: "Shapes", Type: circle.Type, Fields: circle.Fields},
+		}))
+	})
+
+	It("should convert interface fields as registered concrete types", func() {
+		RegisterConcreteType("circle", registryCircle{})
+		defer func() {
+			registryMu.Lock()
+			delete(concreteTypes, "circle")
+			registryGen++
+			registryMu.Unlock()
+		}()
+		Expect(MustToSchema(row{})).To(Equal(MustToSchema(row{}, WithConcreteType("circle", registryCircle{}))))
+	})
+
+	It("should not convert interface fields without a known concrete type", func() {
+		_, err := ToSchema(row{})
+		Expect(err).To(MatchError(`shape: unknown concrete type "circle"`))
+		_, err = ToSchema(struct{ Shape registryShape }{})
+		var inconvertible *ErrInconvertibleType
+		Expect(errors.As(err, &inconvertible)).To(BeTrue())
+		Expect(inconvertible).To(Equal(&ErrInconvertibleType{"bqschema.registryShape"}))
+	})
+})
+
+type registryKey struct {
+	Kind string
+	ID   int64
+}
+
+func (k *registryKey) String() string { return fmt.Sprintf("%s:%d", k.Kind, k.ID) }
+func (k *registryKey) Encode() string { return k.String() }
+
+type registryUserID int64
+
+type registryULID [4]byte
+
+func (u registryULID) String() string { return hex.EncodeToString(u[:]) }
+
+var _ = Describe("String types", func() {
+	type row struct {
+		Key  *registryKey
+		Keys []registryKey
+		ULID registryULID `json:"ulid,omitempty"`
+	}
+
+	isULID := func(t reflect.Type) bool { return t == reflect.TypeOf(registryULID{}) }
+
+	It("should convert keys and declared string types to strings", func() {
+		schema, err := ToSchema(row{}, WithStringType(isULID))
+		Expect(err).To(BeNil())
+		Expect(schema.Fields).To(Equal([]*bigquery.TableFieldSchema{
+			&bigquery.TableFieldSchema{Mode: "required", Name: "Key", Type: "string"},
+			&bigquery.TableFieldSchema{Mode: "repeated", Name: "Keys", Type: "string"},
+			&bigquery.TableFieldSchema{Mode: "nullable", Name: "ulid", Type: "string"},
+		}))
+		Expect(MustToSchema(row{}).Fields[2].Type).To(Equal("integer"))
+	})
+
+	It("should encode registered string types with String", func() {
+		RegisterStringType(isULID)
+		defer func() {
+			registryMu.Lock()
+			stringTypes = nil
+			registryGen++
+			registryMu.Unlock()
+		}()
+		Expect(MustToSchema(row{})).To(Equal(MustToSchema(row{}, WithStringType(isULID))))
+		Expect(structToRow(reflect.ValueOf(row{
+			Key:  &registryKey{"user", 1},
+			Keys: []registryKey{{"user", 2}},
+			ULID: registryULID{0xca, 0xfe},
+		}))).To(Equal(map[string]interface{}{
+			"Key":  "user:1",
+			"Keys": []interface{}{"user:2"},
+			"ulid": "cafe0000",
+		}))
+	})
+
+	It("should encode string types declared with WithStringType with String", func() {
+		value := reflect.ValueOf(row{
+			Key:  &registryKey{"user", 1},
+			Keys: []registryKey{{"user", 2}},
+			ULID: registryULID{0xca, 0xfe},
+		})
+		Expect(newOptions([]Option{WithStringType(isULID)}).structToRow(value)).To(Equal(map[string]interface{}{
+			"Key":  "user:1",
+			"Keys": []interface{}{"user:2"},
+			"ulid": "cafe0000",
+		}))
+		row, err := structToRow(value)
+		Expect(err).To(BeNil())
+		Expect(row["ulid"]).To(Equal(registryULID{0xca, 0xfe}))
+	})
+
+	It("should only convert types implementing fmt.Stringer or of scalar kinds", func() {
+		schema, err := ToSchema(struct{ Money registryMoney }{}, WithStringType(func(reflect.Type) bool { return true }))
+		Expect(err).To(BeNil())
+		Expect(schema.Fields[0].Type).To(Equal("record"))
+	})
+
+	It("should force string columns for named scalar types", func() {
+		type ids struct {
+			ID      registryUserID   `json:"id"`
+			Friends []registryUserID `json:"friends"`
+			Count   int64            `json:"count"`
+		}
+		isUserID := func(t reflect.Type) bool { return t == reflect.TypeOf(registryUserID(0)) }
+		schema, err := ToSchema(ids{}, WithStringType(isUserID))
+		Expect(err).To(BeNil())
+		Expect(schema.Fields).To(Equal([]*bigquery.TableFieldSchema{
+			&bigquery.TableFieldSchema{Mode: "required", Name: "id", Type: "string"},
+			&bigquery.TableFieldSchema{Mode: "repeated", Name: "friends", Type: "string"},
+			&bigquery.TableFieldSchema{Mode: "required", Name: "count", Type: "integer"},
+		}))
+
+		RegisterStringType(isUserID)
+		defer func() {
+			registryMu.Lock()
+			stringTypes = nil
+			registryGen++
+			registryMu.Unlock()
+		}()
+		Expect(structToRow(reflect.ValueOf(ids{ID: 42, Friends: []registryUserID{1, 2}, Count: 3}))).To(Equal(map[string]interface{}{
+			"id":      "42",
+			"friends": []interface{}{"1", "2"},
+			"count":   int64(3),
+		}))
+	})
+})
@@ -0,0 +1,41 @@
+package bqschema
+
+import (
+	"fmt"
+
+	"google.golang.org/api/bigquery/v2"
+)
+
+// Relax returns a copy of schema with every required field, including fields
+// nested in records, made nullable. Relaxed schemas can be appended to existing tables.
+func Relax(schema *bigquery.TableSchema) *bigquery.TableSchema {
+	fields := copyFields(schema.Fields)
+	relaxFields(fields)
+	return &bigquery.TableSchema{Fields: fields}
+}
+
+func relaxFields(fields []*bigquery.TableFieldSchema) {
+	for _, field := range fields {
+		if canonicalMode(field.Mode) == "required" {
+			field.Mode = "nullable"
+		}
+		relaxFields(field.Fields)
+	}
+}
+
+// Require returns a copy of schema with the fields at the dotted paths made required.
+// Repeated fields can not be required.
+func Require(schema *bigquery.TableSchema, paths ...string) (*bigquery.TableSchema, error) {
+	fields := copyFields(schema.Fields)
+	for _, path := range paths {
+		field, err := findField(fields, path)
+		if err != nil {
+			return nil, err
+		}
+		if isRepeated(field) {
+			return nil, fmt.Errorf("%s: repeated fields can not be required", path)
+		}
+		field.Mode = "required"
+	}
+	return &bigquery.TableSchema{Fields: fields}, nil
+}
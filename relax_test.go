@@ -0,0 +1,53 @@
+package bqschema
+
+import (
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+
+	"google.golang.org/api/bigquery/v2"
+)
+
+var _ = Describe("Relax and Require", func() {
+	schema := &bigquery.TableSchema{
+		Fields: []*bigquery.TableFieldSchema{
+			&bigquery.TableFieldSchema{Mode: "required", Name: "A", Type: "integer"},
+			&bigquery.TableFieldSchema{Mode: "repeated", Name: "B", Type: "string"},
+			&bigquery.TableFieldSchema{
+				Mode: "nullable",
+				Name: "C",
+				Type: "record",
+				Fields: []*bigquery.TableFieldSchema{
+					&bigquery.TableFieldSchema{Mode: "REQUIRED", Name: "D", Type: "float"},
+					&bigquery.TableFieldSchema{Name: "E", Type: "boolean"},
+				},
+			},
+		},
+	}
+
+	It("should relax required fields recursively", func() {
+		relaxed := Relax(schema)
+		Expect(relaxed.Fields[0].Mode).To(Equal("nullable"))
+		Expect(relaxed.Fields[1].Mode).To(Equal("repeated"))
+		Expect(relaxed.Fields[2].Fields[0].Mode).To(Equal("nullable"))
+		Expect(schema.Fields[0].Mode).To(Equal("required"))
+	})
+
+	It("should require fields at dotted paths", func() {
+		required, err := Require(Relax(schema), "a", "C.E")
+		Expect(err).To(BeNil())
+		Expect(required.Fields[0].Mode).To(Equal("required"))
+		Expect(required.Fields[2].Mode).To(Equal("nullable"))
+		Expect(required.Fields[2].Fields[0].Mode).To(Equal("nullable"))
+		Expect(required.Fields[2].Fields[1].Mode).To(Equal("required"))
+		Expect(schema.Fields[2].Fields[1].Mode).To(Equal(""))
+	})
+
+	It("should not require repeated or missing fields", func() {
+		_, err := Require(schema, "B")
+		Expect(err).To(MatchError("B: repeated fields can not be required"))
+		_, err = Require(schema, "C.F")
+		Expect(err).To(MatchError("C.F: no such field"))
+		_, err = Require(schema, "A.B")
+		Expect(err).To(MatchError("A: not a record"))
+	})
+})
@@ -0,0 +1,88 @@
+package bqschema
+
+import (
+	"fmt"
+	"strings"
+
+	"google.golang.org/api/bigquery/v2"
+)
+
+// ReportKind classifies the entries of a Report.
+type ReportKind string
+
+const (
+	ReportSkipped ReportKind = "skipped" // a field left out of the schema
+	ReportCoerced ReportKind = "coerced" // a Go type converted to a column type that does not match it exactly
+	ReportRenamed ReportKind = "renamed" // a column named differently than its field
+	ReportMode    ReportKind = "mode"    // a column mode other than the default
+	ReportLogical ReportKind = "logical" // the logical type annotations a column needs in Avro, Parquet and Arrow
+)
+
+// ReportEntry is a single decision made converting a field. Warnings mark
+// decisions that may lose or reject data.
+type ReportEntry struct {
+	Path    string
+	Kind    ReportKind
+	Message string
+	Warning bool
+}
+
+func (e *ReportEntry) String() string {
+	return fmt.Sprintf("%s: %s", e.Path, e.Message)
+}
+
+// Report lists the decisions made converting a type to a schema, for callers to
+// log or to fail on.
+type Report struct {
+	Entries []*ReportEntry
+
+	path []string // of the field being converted
+}
+
+// Warnings returns the entries of the report that are warnings.
+func (r *Report) Warnings() []*ReportEntry {
+	var warnings []*ReportEntry
+	for _, e := range r.Entries {
+		if e.Warning {
+			warnings = append(warnings, e)
+		}
+	}
+	return warnings
+}
+
+// ToSchemaWithReport converts the passed type like ToSchema, also reporting the
+// decisions made converting it.
+func ToSchemaWithReport(src interface{}, opts ...Option) (*bigquery.TableSchema, *Report, error) {
+	if len(opts) == 0 {
+		return defaultConverter.ToSchemaWithReport(src)
+	}
+	return NewConverter(opts...).ToSchemaWithReport(src)
+}
+
+// The methods below are called during conversion and do nothing on a nil Report,
+// which options hold unless a report was requested.
+
+func (r *Report) enter(name string) {
+	if r != nil {
+		r.path = append(r.path, name)
+	}
+}
+
+func (r *Report) leave() {
+	if r != nil {
+		r.path = r.path[:len(r.path)-1]
+	}
+}
+
+// add reports a decision made converting the current field.
+func (r *Report) add(kind ReportKind, warning bool, format string, args ...interface{}) {
+	if r == nil {
+		return
+	}
+	r.Entries = append(r.Entries, &ReportEntry{
+		Path:    strings.Join(r.path, "."),
+		Kind:    kind,
+		Message: fmt.Sprintf(format, args...),
+		Warning: warning,
+	})
+}
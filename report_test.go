@@ -0,0 +1,62 @@
+package bqschema
+
+import (
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+)
+
+var _ = Describe("Report", func() {
+	type address struct {
+		City string `json:"city"`
+	}
+	type row struct {
+		ID      uint64
+		Name    string `json:"name,omitempty"`
+		Score   *float64
+		Tags    []string
+		Address address
+		Secret  string `json:"-"`
+		note    string
+	}
+
+	It("should report skipped fields, coercions, renames and modes", func() {
+		schema, report, err := ToSchemaWithReport(row{})
+		Expect(err).To(BeNil())
+		Expect(schema).To(Equal(MustToSchema(row{})))
+		Expect(report.Entries).To(Equal([]*ReportEntry{
+			&ReportEntry{Path: "ID", Kind: ReportCoerced, Message: "uint64 converted to integer; values above 9223372036854775807 overflow", Warning: true},
+			&ReportEntry{Path: "name", Kind: ReportRenamed, Message: "renamed from Name"},
+			&ReportEntry{Path: "name", Kind: ReportMode, Message: "nullable as tagged omitempty"},
+			&ReportEntry{Path: "Score", Kind: ReportMode, Message: "pointer field is required; nil values will be rejected", Warning: true},
+			&ReportEntry{Path: "Tags", Kind: ReportMode, Message: "repeated for []string"},
+			&ReportEntry{Path: "Address.city", Kind: ReportRenamed, Message: "renamed from City"},
+			&ReportEntry{Path: "Address", Kind: ReportMode, Message: "nullable as a record"},
+			&ReportEntry{Path: "Secret", Kind: ReportSkipped, Message: "skipped by tag"},
+			&ReportEntry{Path: "note", Kind: ReportSkipped, Message: "unexported field"},
+		}))
+		Expect(report.Warnings()).To(HaveLen(2))
+		Expect(report.Warnings()[0].String()).To(Equal("ID: uint64 converted to integer; values above 9223372036854775807 overflow"))
+	})
+
+	It("should follow the options of the conversion", func() {
+		_, report, err := ToSchemaWithReport(row{}, WithDefaultMode(Nullable))
+		Expect(err).To(BeNil())
+		Expect(report.Warnings()).To(HaveLen(1))
+	})
+
+	It("should report conversions served from a converter's cache", func() {
+		c := NewConverter()
+		c.MustToSchema(row{})
+		_, report, err := c.ToSchemaWithReport(row{})
+		Expect(err).To(BeNil())
+		Expect(report.Entries).To(HaveLen(9))
+	})
+
+	It("should warn of slices whose nil elements are dropped", func() {
+		_, report, err := ToSchemaWithReport(struct{ Addresses []*address }{})
+		Expect(err).To(BeNil())
+		Expect(report.Warnings()).To(Equal([]*ReportEntry{
+			&ReportEntry{Path: "Addresses", Kind: ReportMode, Message: "nil elements of []*bqschema.address are dropped; BigQuery arrays can not hold null", Warning: true},
+		}))
+	})
+})
@@ -0,0 +1,273 @@
+package bqschema
+
+import (
+	"encoding/base64"
+	"errors"
+	"fmt"
+	"math"
+	"reflect"
+	"strconv"
+	"strings"
+	"time"
+
+	"google.golang.org/api/bigquery/v2"
+)
+
+var ErrNotSlicePointer = errors.New("Can not decode into non pointers to slices of structs")
+
+// SchemaUnmarshaler is implemented by types decoding themselves from the cells
+// of query results, the counterpart of SchemaMarshaler, such as the types of money amounts, UUIDs or enums mapped
+// to columns with RegisterTypeMapping or RegisterStringType. UnmarshalBigQuery
+// is given the value of the cell as returned by the API: a string for scalars,
+// a []interface{} of elements for repeated columns and a map with the "f" list
+// of fields for records. NULL cells decode to zero values without calling it.
+// The errors it returns are wrapped, with the path of the column, so
+// errors.Is and errors.As see them through the error of RowsToStructs.
+type SchemaUnmarshaler interface {
+	UnmarshalBigQuery(value interface{}) error
+}
+
+var schemaUnmarshalerType = reflect.TypeOf((*SchemaUnmarshaler)(nil)).Elem()
+
+// ResultsToStructs decodes the rows of a query response, appending a struct to
+// the slice dst points to for each row. See RowsToStructs.
+func ResultsToStructs(resp *bigquery.QueryResponse, dst interface{}, opts ...Option) error {
+	return RowsToStructs(resp.Schema, resp.Rows, dst, opts...)
+}
+
+// RowsToStructs decodes rows of the given schema, appending a struct to the
+// slice dst points to for each row, so the pages of jobs.getQueryResults or
+// tabledata.list can be decoded one after the other into the same slice.
+// dst is a pointer to a slice of structs or of pointers to structs.
+//
+// Columns are matched to fields by the names ToSchema gives them with opts,
+// ignoring case; columns without a field are ignored. Records decode into structs,
+// repeated columns into slices and NULL into zero values or nil pointers.
+// TIMESTAMP, DATETIME, DATE and TIME columns decode into time.Time fields.
+// Fields of types implementing SchemaUnmarshaler, through a pointer or not,
+// decode themselves.
+func RowsToStructs(schema *bigquery.TableSchema, rows []*bigquery.TableRow, dst interface{}, opts ...Option) error {
+	v := reflect.ValueOf(dst)
+	if v.Kind() != reflect.Ptr || v.Elem().Kind() != reflect.Slice {
+		return ErrNotSlicePointer
+	}
+	slice := v.Elem()
+	elemType := slice.Type().Elem()
+	structType := elemType
+	if structType.Kind() == reflect.Ptr {
+		structType = structType.Elem()
+	}
+	if structType.Kind() != reflect.Struct {
+		return ErrNotSlicePointer
+	}
+
+	o := newOptions(opts)
+	decoded := reflect.MakeSlice(slice.Type(), 0, len(rows))
+	for i, row := range rows {
+		item := reflect.New(structType)
+		if err := o.decodeRecord(schema.Fields, row.F, item.Elem(), ""); err != nil {
+			return fmt.Errorf("row %d: %w", slice.Len()+i, err)
+		}
+		if elemType.Kind() == reflect.Ptr {
+			decoded = reflect.Append(decoded, item)
+		} else {
+			decoded = reflect.Append(decoded, item.Elem())
+		}
+	}
+	slice.Set(reflect.AppendSlice(slice, decoded))
+	return nil
+}
+
+func (o *options) decodeRecord(fields []*bigquery.TableFieldSchema, cells []*bigquery.TableCell, dst reflect.Value, prefix string) error {
+	t := dst.Type()
+	index := make(map[string]int, t.NumField())
+	for _, fp := range o.plan(t).fields {
+		if !fp.tag.skip && fp.tag.accessor == "" {
+			index[strings.ToLower(fp.tag.name)] = fp.index
+		}
+	}
+
+	for i, cell := range cells {
+		if i >= len(fields) {
+			break
+		}
+		field := fields[i]
+		fi, ok := index[strings.ToLower(field.Name)]
+		if !ok {
+			continue
+		}
+		if err := o.decodeValue(field, cell.V, dst.Field(fi), prefix+field.Name); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// decodeValue decodes the value of a cell, as found in TableCell.V, into dst.
+// Errors are FieldErrors of kind KindDecode for the column at path.
+func (o *options) decodeValue(field *bigquery.TableFieldSchema, value interface{}, dst reflect.Value, path string) error {
+	if value == nil {
+		dst.Set(reflect.Zero(dst.Type()))
+		return nil
+	}
+	if dst.CanAddr() && reflect.PtrTo(dst.Type()).Implements(schemaUnmarshalerType) {
+		return decodeError(path, dst.Addr().Interface().(SchemaUnmarshaler).UnmarshalBigQuery(value))
+	}
+	if dst.Kind() == reflect.Ptr {
+		elem := reflect.New(dst.Type().Elem())
+		if err := o.decodeValue(field, value, elem.Elem(), path); err != nil {
+			return err
+		}
+		dst.Set(elem)
+		return nil
+	}
+
+	if isRepeated(field) && dst.Kind() == reflect.Map {
+		return o.decodeMap(field, value, dst, path)
+	}
+	if isRepeated(field) {
+		values, ok := value.([]interface{})
+		if !ok || dst.Kind() != reflect.Slice {
+			return decodeError(path, fmt.Errorf("can not decode repeated %s into %s", strings.ToLower(field.Type), dst.Type()))
+		}
+		elemField := *field
+		elemField.Mode = "nullable"
+		slice := reflect.MakeSlice(dst.Type(), len(values), len(values))
+		for i, v := range values {
+			if err := o.decodeValue(&elemField, cellValue(v), slice.Index(i), path); err != nil {
+				return err
+			}
+		}
+		dst.Set(slice)
+		return nil
+	}
+
+	if canonicalType(field.Type) == "record" {
+		record, ok := value.(map[string]interface{})
+		if !ok || dst.Kind() != reflect.Struct {
+			return decodeError(path, fmt.Errorf("can not decode record into %s", dst.Type()))
+		}
+		values, _ := record["f"].([]interface{})
+		cells := make([]*bigquery.TableCell, len(values))
+		for i, v := range values {
+			cells[i] = &bigquery.TableCell{V: cellValue(v)}
+		}
+		return o.decodeRecord(field.Fields, cells, dst, path+".")
+	}
+
+	s, ok := value.(string)
+	if !ok {
+		return decodeError(path, fmt.Errorf("can not decode %T into %s", value, dst.Type()))
+	}
+	return decodeError(path, decodeScalar(field.Type, s, dst))
+}
+
+// decodeError returns err, if any, as the FieldError of the column at path.
+func decodeError(path string, err error) error {
+	if err == nil {
+		return nil
+	}
+	return &FieldError{Path: path, Kind: KindDecode, Wrapped: err}
+}
+
+// decodeMap decodes a repeated record of key and value columns into the map dst.
+func (o *options) decodeMap(field *bigquery.TableFieldSchema, value interface{}, dst reflect.Value, path string) error {
+	values, ok := value.([]interface{})
+	entry, isMap := mapEntryType(dst.Type())
+	if !ok || !isMap || canonicalType(field.Type) != "record" {
+		return decodeError(path, fmt.Errorf("can not decode repeated %s into %s", strings.ToLower(field.Type), dst.Type()))
+	}
+	elemField := *field
+	elemField.Mode = "nullable"
+	m := reflect.MakeMapWithSize(dst.Type(), len(values))
+	for _, v := range values {
+		e := reflect.New(entry).Elem()
+		if err := o.decodeValue(&elemField, cellValue(v), e, path); err != nil {
+			return err
+		}
+		m.SetMapIndex(e.Field(0), e.Field(1))
+	}
+	dst.Set(m)
+	return nil
+}
+
+// cellValue unwraps the {"v": value} objects holding the elements of arrays and the fields of records.
+func cellValue(v interface{}) interface{} {
+	if m, ok := v.(map[string]interface{}); ok {
+		return m["v"]
+	}
+	return v
+}
+
+func decodeScalar(bqType, s string, dst reflect.Value) error {
+	if dst.Type() == reflect.TypeOf(time.Time{}) {
+		t, err := parseTime(bqType, s)
+		if err != nil {
+			return err
+		}
+		dst.Set(reflect.ValueOf(t))
+		return nil
+	}
+
+	switch dst.Kind() {
+	case reflect.String:
+		dst.SetString(s)
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64:
+		i, err := strconv.ParseInt(s, 10, dst.Type().Bits())
+		if err != nil {
+			return err
+		}
+		dst.SetInt(i)
+	case reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64:
+		u, err := strconv.ParseUint(s, 10, dst.Type().Bits())
+		if err != nil {
+			return err
+		}
+		dst.SetUint(u)
+	case reflect.Float32, reflect.Float64:
+		f, err := strconv.ParseFloat(s, dst.Type().Bits())
+		if err != nil {
+			return err
+		}
+		dst.SetFloat(f)
+	case reflect.Bool:
+		b, err := strconv.ParseBool(s)
+		if err != nil {
+			return err
+		}
+		dst.SetBool(b)
+	case reflect.Slice:
+		if dst.Type().Elem().Kind() != reflect.Uint8 {
+			return fmt.Errorf("can not decode %s into %s", strings.ToLower(bqType), dst.Type())
+		}
+		b, err := base64.StdEncoding.DecodeString(s)
+		if err != nil {
+			return err
+		}
+		dst.SetBytes(b)
+	case reflect.Interface:
+		dst.Set(reflect.ValueOf(s))
+	default:
+		return fmt.Errorf("can not decode %s into %s", strings.ToLower(bqType), dst.Type())
+	}
+	return nil
+}
+
+// parseTime parses a time as returned in query results: TIMESTAMPs are
+// seconds since the epoch, the other types are formatted like formatTime does.
+func parseTime(bqType, s string) (time.Time, error) {
+	switch strings.ToLower(bqType) {
+	case "datetime":
+		return time.Parse("2006-01-02T15:04:05.999999", s)
+	case "date":
+		return time.Parse("2006-01-02", s)
+	case "time":
+		return time.Parse("15:04:05.999999", s)
+	default:
+		f, err := strconv.ParseFloat(s, 64)
+		if err != nil {
+			return time.Time{}, err
+		}
+		return time.Unix(0, int64(math.Round(f*1e6))*int64(time.Microsecond)).UTC(), nil
+	}
+}
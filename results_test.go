@@ -0,0 +1,158 @@
+package bqschema
+
+import (
+	"errors"
+	"math"
+	"strconv"
+	"strings"
+	"time"
+
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+
+	"google.golang.org/api/bigquery/v2"
+)
+
+var _ = Describe("ResultsToStructs", func() {
+	type address struct {
+		City string `json:"city"`
+	}
+	type user struct {
+		ID      int64 `json:"id"`
+		Score   *float64
+		At      time.Time
+		Tags    []string
+		Address *address
+		Photo   []byte
+	}
+
+	schema := &bigquery.TableSchema{Fields: []*bigquery.TableFieldSchema{
+		&bigquery.TableFieldSchema{Name: "ID", Type: "INTEGER"},
+		&bigquery.TableFieldSchema{Name: "score", Type: "FLOAT"},
+		&bigquery.TableFieldSchema{Name: "at", Type: "TIMESTAMP"},
+		&bigquery.TableFieldSchema{Name: "tags", Type: "STRING", Mode: "REPEATED"},
+		&bigquery.TableFieldSchema{Name: "address", Type: "RECORD", Fields: []*bigquery.TableFieldSchema{
+			&bigquery.TableFieldSchema{Name: "city", Type: "STRING"},
+		}},
+		&bigquery.TableFieldSchema{Name: "photo", Type: "BYTES"},
+		&bigquery.TableFieldSchema{Name: "extra", Type: "STRING"},
+	}}
+
+	page := func(id string, score interface{}) []*bigquery.TableRow {
+		return []*bigquery.TableRow{&bigquery.TableRow{F: []*bigquery.TableCell{
+			{V: id},
+			{V: score},
+			{V: "1.5778368001E9"},
+			{V: []interface{}{map[string]interface{}{"v": "a"}, map[string]interface{}{"v": "b"}}},
+			{V: map[string]interface{}{"f": []interface{}{map[string]interface{}{"v": "Paris"}}}},
+			{V: "aGk="},
+			{V: "ignored"},
+		}}}
+	}
+
+	It("should decode rows into structs", func() {
+		var users []user
+		Expect(ResultsToStructs(&bigquery.QueryResponse{Schema: schema, Rows: page("1", "2.5")}, &users)).To(Succeed())
+		score := 2.5
+		Expect(users).To(Equal([]user{{
+			ID:      1,
+			Score:   &score,
+			At:      time.Date(2020, 1, 1, 0, 0, 0, 100000000, time.UTC),
+			Tags:    []string{"a", "b"},
+			Address: &address{City: "Paris"},
+			Photo:   []byte("hi"),
+		}}))
+	})
+
+	It("should append the rows of every page", func() {
+		var users []*user
+		Expect(RowsToStructs(schema, page("1", nil), &users)).To(Succeed())
+		Expect(RowsToStructs(schema, page("2", nil), &users)).To(Succeed())
+		Expect(users).To(HaveLen(2))
+		Expect(users[1].ID).To(BeNumerically("==", 2))
+		Expect(users[1].Score).To(BeNil())
+	})
+
+	It("should report values that can not be decoded", func() {
+		var users []user
+		Expect(RowsToStructs(schema, page("1", nil), &users)).To(Succeed())
+		err := RowsToStructs(schema, page("x", nil), &users)
+		Expect(err).To(MatchError(`row 1: ID: strconv.ParseInt: parsing "x": invalid syntax`))
+		Expect(users).To(HaveLen(1))
+		Expect(RowsToStructs(schema, nil, users)).To(Equal(ErrNotSlicePointer))
+	})
+
+	It("should report the path of nested values that can not be decoded once", func() {
+		type numbered struct {
+			Address struct {
+				City int `json:"city"`
+			}
+		}
+		var rows []numbered
+		err := RowsToStructs(schema, page("1", nil), &rows)
+		Expect(err).To(MatchError(`row 0: address.city: strconv.ParseInt: parsing "Paris": invalid syntax`))
+		Expect(errors.Is(err, &FieldError{Path: "address.city", Kind: KindDecode})).To(BeTrue())
+		Expect(errors.Is(err, strconv.ErrSyntax)).To(BeTrue())
+
+		var tagged []struct{ Tags []int }
+		err = RowsToStructs(schema, page("1", nil), &tagged)
+		Expect(err).To(MatchError(`row 0: tags: strconv.ParseInt: parsing "a": invalid syntax`))
+	})
+
+	It("should let types implementing SchemaUnmarshaler decode themselves", func() {
+		type order struct {
+			Total    resultsCents   `json:"total"`
+			Discount *resultsCents  `json:"discount"`
+			Codes    resultsCodes   `json:"codes"`
+			Lines    []resultsCents `json:"lines"`
+		}
+		schema := &bigquery.TableSchema{Fields: []*bigquery.TableFieldSchema{
+			&bigquery.TableFieldSchema{Name: "total", Type: "NUMERIC"},
+			&bigquery.TableFieldSchema{Name: "discount", Type: "NUMERIC"},
+			&bigquery.TableFieldSchema{Name: "codes", Type: "STRING", Mode: "REPEATED"},
+			&bigquery.TableFieldSchema{Name: "lines", Type: "NUMERIC", Mode: "REPEATED"},
+		}}
+		row := func(total string, discount interface{}) []*bigquery.TableRow {
+			return []*bigquery.TableRow{&bigquery.TableRow{F: []*bigquery.TableCell{
+				{V: total},
+				{V: discount},
+				{V: []interface{}{map[string]interface{}{"v": "A"}, map[string]interface{}{"v": "b"}}},
+				{V: []interface{}{map[string]interface{}{"v": "0.5"}}},
+			}}}
+		}
+
+		var orders []order
+		Expect(RowsToStructs(schema, row("12.34", "1"), &orders)).To(Succeed())
+		Expect(RowsToStructs(schema, row("0", nil), &orders)).To(Succeed())
+		discount := resultsCents(100)
+		Expect(orders).To(Equal([]order{
+			{Total: 1234, Discount: &discount, Codes: resultsCodes{"a", "b"}, Lines: []resultsCents{50}},
+			{Total: 0, Codes: resultsCodes{"a", "b"}, Lines: []resultsCents{50}},
+		}))
+
+		err := RowsToStructs(schema, row("x", nil), &orders)
+		Expect(err).To(MatchError(`row 2: total: strconv.ParseFloat: parsing "x": invalid syntax`))
+		var numErr *strconv.NumError
+		Expect(errors.As(err, &numErr)).To(BeTrue())
+		Expect(numErr.Num).To(Equal("x"))
+	})
+})
+
+// resultsCents decodes NUMERIC amounts into cents.
+type resultsCents int64
+
+func (c *resultsCents) UnmarshalBigQuery(value interface{}) error {
+	f, err := strconv.ParseFloat(value.(string), 64)
+	*c = resultsCents(math.Round(f * 100))
+	return err
+}
+
+// resultsCodes decodes repeated codes in lower case.
+type resultsCodes []string
+
+func (c *resultsCodes) UnmarshalBigQuery(value interface{}) error {
+	for _, v := range value.([]interface{}) {
+		*c = append(*c, strings.ToLower(v.(map[string]interface{})["v"].(string)))
+	}
+	return nil
+}
@@ -0,0 +1,117 @@
+// Package schematest checks the BigQuery schemas of Go types against golden
+// files, so that schema changes show up in code review:
+//
+//	func TestEventSchema(t *testing.T) {
+//		schematest.AssertSchema(t, Event{}, "testdata/event.schema.json")
+//	}
+//
+// Golden files hold the fields of the schema as indented JSON, in the format
+// of bq show --schema, with upper case types and modes. Running the tests with
+// the BQSCHEMA_UPDATE_GOLDEN environment variable set writes them instead:
+//
+//	BQSCHEMA_UPDATE_GOLDEN=1 go test ./...
+package schematest
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/nbio/bqschema"
+	"google.golang.org/api/bigquery/v2"
+)
+
+// UpdateEnv names the environment variable which, set to a non empty value,
+// makes AssertSchema write golden files rather than compare against them.
+const UpdateEnv = "BQSCHEMA_UPDATE_GOLDEN"
+
+// AssertSchema fails t if the schema of src converted with opts differs from
+// the golden file at goldenPath, or writes the file if UpdateEnv is set.
+func AssertSchema(t testing.TB, src interface{}, goldenPath string, opts ...bqschema.Option) {
+	t.Helper()
+
+	schema, err := bqschema.ToSchema(src, opts...)
+	if err != nil {
+		t.Fatalf("%s: %s", goldenPath, err)
+		return
+	}
+	got, err := Marshal(schema)
+	if err != nil {
+		t.Fatalf("%s: %s", goldenPath, err)
+		return
+	}
+
+	if os.Getenv(UpdateEnv) != "" {
+		if err := os.MkdirAll(filepath.Dir(goldenPath), 0755); err != nil {
+			t.Fatalf("%s: %s", goldenPath, err)
+			return
+		}
+		if err := ioutil.WriteFile(goldenPath, got, 0644); err != nil {
+			t.Fatalf("%s: %s", goldenPath, err)
+		}
+		return
+	}
+
+	data, err := ioutil.ReadFile(goldenPath)
+	if os.IsNotExist(err) {
+		t.Fatalf("%s: no golden file; run the test with %s=1 to write it", goldenPath, UpdateEnv)
+		return
+	} else if err != nil {
+		t.Fatalf("%s: %s", goldenPath, err)
+		return
+	}
+	golden := &bigquery.TableSchema{}
+	if err := json.Unmarshal(data, &golden.Fields); err != nil {
+		t.Fatalf("%s: %s", goldenPath, err)
+		return
+	}
+	want, err := Marshal(golden)
+	if err != nil {
+		t.Fatalf("%s: %s", goldenPath, err)
+		return
+	}
+
+	if !bytes.Equal(got, want) {
+		t.Errorf("%s: schema of %T differs from the golden file:\n%s\nrun the test with %s=1 to update it",
+			goldenPath, src, describe(golden, schema, want, got), UpdateEnv)
+	}
+}
+
+// Marshal returns the fields of schema in the normalized JSON of golden files.
+func Marshal(schema *bigquery.TableSchema) ([]byte, error) {
+	data, err := json.MarshalIndent(normalize(schema.Fields), "", "  ")
+	if err != nil {
+		return nil, err
+	}
+	return append(data, '\n'), nil
+}
+
+func normalize(fields []*bigquery.TableFieldSchema) []*bigquery.TableFieldSchema {
+	if fields == nil {
+		return []*bigquery.TableFieldSchema{}
+	}
+	normalized := make([]*bigquery.TableFieldSchema, len(fields))
+	for i, field := range fields {
+		f := *field
+		f.Type = strings.ToUpper(f.Type)
+		f.Mode = strings.ToUpper(f.Mode)
+		if f.Fields != nil {
+			f.Fields = normalize(f.Fields)
+		}
+		normalized[i] = &f
+	}
+	return normalized
+}
+
+// describe lists the differences between the golden schema and the converted one.
+func describe(golden, schema *bigquery.TableSchema, want, got []byte) string {
+	if changes := bqschema.Changelog(golden, schema); changes != "" {
+		return changes
+	}
+	return fmt.Sprintf("want:\n%s\ngot:\n%s", want, got)
+}
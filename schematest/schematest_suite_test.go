@@ -0,0 +1,13 @@
+package schematest
+
+import (
+	"testing"
+
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+)
+
+func TestSchematest(t *testing.T) {
+	RegisterFailHandler(Fail)
+	RunSpecs(t, "Schematest Suite")
+}
@@ -0,0 +1,153 @@
+package schematest
+
+import (
+	"fmt"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/nbio/bqschema"
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+)
+
+// recorder records the failures of AssertSchema.
+type recorder struct {
+	testing.TB
+	errors []string
+	fatal  bool
+}
+
+func (r *recorder) Helper() {}
+
+func (r *recorder) Errorf(format string, args ...interface{}) {
+	r.errors = append(r.errors, fmt.Sprintf(format, args...))
+}
+
+func (r *recorder) Fatalf(format string, args ...interface{}) {
+	r.Errorf(format, args...)
+	r.fatal = true
+}
+
+var _ = Describe("AssertSchema", func() {
+	type address struct {
+		City string `json:"city"`
+	}
+	type user struct {
+		ID      int64   `json:"id"`
+		Name    string  `json:"name,omitempty" description:"Display name."`
+		Address address `json:"address"`
+	}
+	type renamed struct {
+		ID      int64   `json:"id"`
+		Name    int64   `json:"name"`
+		Address address `json:"address"`
+		Email   string  `json:"email"`
+	}
+
+	var dir, golden string
+
+	BeforeEach(func() {
+		var err error
+		dir, err = ioutil.TempDir("", "schematest")
+		Expect(err).To(BeNil())
+		golden = filepath.Join(dir, "testdata", "user.schema.json")
+	})
+
+	AfterEach(func() {
+		os.Unsetenv(UpdateEnv)
+		os.RemoveAll(dir)
+	})
+
+	It("should write normalized golden files when updating", func() {
+		os.Setenv(UpdateEnv, "1")
+		r := &recorder{}
+		AssertSchema(r, user{}, golden)
+		Expect(r.errors).To(BeEmpty())
+
+		data, err := ioutil.ReadFile(golden)
+		Expect(err).To(BeNil())
+		Expect(string(data)).To(Equal(`[
+  {
+    "mode": "REQUIRED",
+    "name": "id",
+    "type": "INTEGER"
+  },
+  {
+    "description": "Display name.",
+    "mode": "NULLABLE",
+    "name": "name",
+    "type": "STRING"
+  },
+  {
+    "fields": [
+      {
+        "mode": "REQUIRED",
+        "name": "city",
+        "type": "STRING"
+      }
+    ],
+    "mode": "NULLABLE",
+    "name": "address",
+    "type": "RECORD"
+  }
+]
+`))
+	})
+
+	It("should pass when the schema matches the golden file", func() {
+		os.Setenv(UpdateEnv, "1")
+		AssertSchema(&recorder{}, user{}, golden)
+		os.Unsetenv(UpdateEnv)
+
+		r := &recorder{}
+		AssertSchema(r, user{}, golden)
+		Expect(r.errors).To(BeEmpty())
+	})
+
+	It("should describe drift from the golden file", func() {
+		os.Setenv(UpdateEnv, "1")
+		AssertSchema(&recorder{}, user{}, golden)
+		os.Unsetenv(UpdateEnv)
+
+		r := &recorder{}
+		AssertSchema(r, renamed{}, golden)
+		Expect(r.fatal).To(BeFalse())
+		Expect(r.errors).To(Equal([]string{golden + ": schema of schematest.renamed differs from the golden file:\n" +
+			"- Added column `email` (STRING, REQUIRED)\n" +
+			"- Changed type of `name` from STRING to INTEGER\n" +
+			"- Changed mode of `name` from NULLABLE to REQUIRED\n" +
+			"- Changed description of `name` to \"\"\n" +
+			"\nrun the test with BQSCHEMA_UPDATE_GOLDEN=1 to update it"}))
+	})
+
+	It("should convert with options", func() {
+		os.Setenv(UpdateEnv, "1")
+		AssertSchema(&recorder{}, user{}, golden, bqschema.WithDefaultMode("nullable"))
+		os.Unsetenv(UpdateEnv)
+
+		r := &recorder{}
+		AssertSchema(r, user{}, golden)
+		Expect(r.errors).To(HaveLen(1))
+		Expect(r.errors[0]).To(ContainSubstring("- Changed mode of `id` from NULLABLE to REQUIRED"))
+
+		r = &recorder{}
+		AssertSchema(r, user{}, golden, bqschema.WithDefaultMode("nullable"))
+		Expect(r.errors).To(BeEmpty())
+	})
+
+	It("should fail without a golden file", func() {
+		r := &recorder{}
+		AssertSchema(r, user{}, golden)
+		Expect(r.fatal).To(BeTrue())
+		Expect(r.errors).To(Equal([]string{golden + ": no golden file; run the test with BQSCHEMA_UPDATE_GOLDEN=1 to write it"}))
+	})
+
+	It("should fail on types that do not convert", func() {
+		r := &recorder{}
+		AssertSchema(r, 1, golden)
+		Expect(r.fatal).To(BeTrue())
+		Expect(r.errors).To(Equal([]string{golden + ": Can not convert non structs"}))
+	})
+})
@@ -0,0 +1,65 @@
+package bqschema
+
+import (
+	"math"
+	"reflect"
+	"strings"
+	"time"
+)
+
+var durationType = reflect.TypeOf(time.Duration(0))
+
+// moneySuffixes end the names of fields the default heuristic of WithStrict takes for amounts of money.
+var moneySuffixes = []string{"amount", "balance", "cost", "fee", "price", "total"}
+
+// WithStrict makes conversions fail on mappings that lose information instead
+// of reporting them: unsigned 64 bit integers and time.Duration converted to
+// INTEGER, interface fields, unexported fields skipped without a "-" tag, and
+// float fields holding amounts of money, which need a NUMERIC column. Money
+// fields are those whose column name ends with amount, balance, cost, fee,
+// price or total, unless WithMoneyFields declares otherwise.
+func WithStrict() Option {
+	return func(o *options) {
+		o.strict = true
+	}
+}
+
+// WithMoneyFields replaces the heuristic telling which columns hold amounts of money.
+func WithMoneyFields(isMoney func(column string) bool) Option {
+	return func(o *options) {
+		o.isMoney = isMoney
+	}
+}
+
+func isMoney(column string) bool {
+	column = strings.ToLower(column)
+	for _, suffix := range moneySuffixes {
+		if strings.HasSuffix(column, suffix) {
+			return true
+		}
+	}
+	return false
+}
+
+// lossy fails the conversion of the field with the given reason in strict mode
+// and reports it as a warning otherwise.
+func (o *options) lossy(format string, args ...interface{}) error {
+	if o.strict {
+		return fieldError(KindLossy, format, args...)
+	}
+	o.report.add(ReportCoerced, true, format, args...)
+	return nil
+}
+
+// checkScalar checks the conversion of a field of type t to a column of a simple type.
+func (o *options) checkScalar(name string, t reflect.Type) error {
+	switch {
+	case t == durationType:
+		return o.lossy("time.Duration converted to integer nanoseconds")
+	case t.Kind() == reflect.Uint || t.Kind() == reflect.Uint64:
+		return o.lossy("%s converted to integer; values above %d overflow", t, math.MaxInt64)
+	case (t.Kind() == reflect.Float32 || t.Kind() == reflect.Float64) && o.isMoney(name):
+		return o.lossy("money converted to float; map it to a numeric column")
+	}
+	return nil
+}
@@ -0,0 +1,59 @@
+package bqschema
+
+import (
+	"strings"
+	"time"
+
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+)
+
+var _ = Describe("WithStrict", func() {
+	It("should reject lossy mappings", func() {
+		table := [][]interface{}{
+			[]interface{}{struct{ N uint64 }{}, "N: uint64 converted to integer; values above 9223372036854775807 overflow"},
+			[]interface{}{struct{ N []uint }{}, "N: uint converted to integer; values above 9223372036854775807 overflow"},
+			[]interface{}{struct{ Timeout time.Duration }{}, "Timeout: time.Duration converted to integer nanoseconds"},
+			[]interface{}{struct {
+				Shape registryShape `bigquery:",as=circle"`
+			}{}, "Shape: interface fields are not allowed in strict mode"},
+			[]interface{}{struct {
+				UnitPrice float64 `json:"unit_price"`
+			}{}, "unit_price: money converted to float; map it to a numeric column"},
+			[]interface{}{struct{ note string }{}, "note: unexported field skipped; tag it `bqschema:\"export\"` or `bigquery:\"-\"`"},
+		}
+		for _, data := range table {
+			circle := WithConcreteType("circle", registryCircle{})
+			_, err := ToSchema(data[0], WithStrict(), circle)
+			Expect(err).To(MatchError(data[1]))
+			_, err = ToSchema(data[0], circle)
+			Expect(err).To(BeNil())
+		}
+	})
+
+	It("should accept explicit mappings", func() {
+		_, err := ToSchema(struct {
+			ID    int64
+			Price string `bigquery:",type=numeric"`
+			Ratio float64
+			note  string `bigquery:"-"`
+		}{}, WithStrict())
+		Expect(err).To(BeNil())
+	})
+
+	It("should use the configured money heuristic", func() {
+		src := struct{ Cents float64 }{}
+		_, err := ToSchema(src, WithStrict())
+		Expect(err).To(BeNil())
+		_, err = ToSchema(src, WithStrict(), WithMoneyFields(func(column string) bool {
+			return strings.HasSuffix(column, "Cents")
+		}))
+		Expect(err).To(MatchError("Cents: money converted to float; map it to a numeric column"))
+	})
+
+	It("should report lossy mappings as warnings otherwise", func() {
+		_, report, err := ToSchemaWithReport(struct{ Timeout time.Duration }{})
+		Expect(err).To(BeNil())
+		Expect(report.Warnings()[0].String()).To(Equal("Timeout: time.Duration converted to integer nanoseconds"))
+	})
+})
@@ -0,0 +1,177 @@
+package bqschema
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net/http"
+	"reflect"
+	"strings"
+	"time"
+
+	"google.golang.org/api/bigquery/v2"
+	"google.golang.org/api/googleapi"
+)
+
+// TableOptions holds the table level configuration of a type, applied by
+// ToTableMetadata and EnsureTable.
+type TableOptions struct {
+	Description string
+	Labels      map[string]string
+	// Expiration is the lifetime of the table, counted from its creation.
+	Expiration time.Duration
+	// RequirePartitionFilter rejects queries not filtering on the partitioning column.
+	RequirePartitionFilter bool
+}
+
+// TableOptioner is implemented by types declaring the options of their table,
+// so the whole table definition lives next to the type:
+//
+//	func (event) BigQueryTableOptions() bqschema.TableOptions {
+//		return bqschema.TableOptions{
+//			Description: "Events sent by clients",
+//			Labels:      map[string]string{"team": "growth"},
+//			Expiration:  90 * 24 * time.Hour,
+//		}
+//	}
+type TableOptioner interface {
+	BigQueryTableOptions() TableOptions
+}
+
+// TableOptionsOf returns the table options declared by src, or by a pointer to
+// it, and whether it declares any.
+func TableOptionsOf(src interface{}) (TableOptions, bool) {
+	if o, ok := src.(TableOptioner); ok {
+		return o.BigQueryTableOptions(), true
+	}
+	v := reflect.ValueOf(src)
+	if !v.IsValid() {
+		return TableOptions{}, false
+	}
+	p := reflect.New(v.Type())
+	p.Elem().Set(v)
+	if o, ok := p.Interface().(TableOptioner); ok {
+		return o.BigQueryTableOptions(), true
+	}
+	return TableOptions{}, false
+}
+
+// ToTableMetadata builds the definition of the table project.dataset.table
+// holding rows of src: the schema of src converted with opts, the options src
+// declares and the schema version it declares, stamped by StampVersion. An
+// expiration is counted from now.
+func ToTableMetadata(src interface{}, project, dataset, table string, opts ...Option) (*bigquery.Table, error) {
+	schema, err := ToSchema(src, opts...)
+	if err != nil {
+		return nil, err
+	}
+
+	t := &bigquery.Table{
+		Schema: schema,
+		TableReference: &bigquery.TableReference{
+			ProjectId: project,
+			DatasetId: dataset,
+			TableId:   table,
+		},
+	}
+	to, _ := TableOptionsOf(src)
+	t.Description = to.Description
+	if len(to.Labels) > 0 {
+		t.Labels = make(map[string]string, len(to.Labels))
+		for k, v := range to.Labels {
+			t.Labels[k] = v
+		}
+	}
+	if to.Expiration > 0 {
+		t.ExpirationTime = time.Now().Add(to.Expiration).UnixNano() / int64(time.Millisecond)
+	}
+	t.RequirePartitionFilter = to.RequirePartitionFilter
+	StampVersion(t, src)
+	return t, nil
+}
+
+// EnsureTable creates the table project.dataset.table as ToTableMetadata
+// defines it with opts, or updates the table if it exists: columns src adds are
+// appended, with the fields of added records nullable, required columns src
+// relaxes are made nullable and the options are applied, the expiration being
+// counted from the creation of the table. Tables whose columns src removes,
+// retypes or tightens are left untouched and reported as an error, as BigQuery
+// can not migrate them. Tables already up to date are returned as they are,
+// without a PATCH request.
+func EnsureTable(ctx context.Context, svc *bigquery.Service, project, dataset, table string, src interface{}, opts ...Option) (*bigquery.Table, error) {
+	t, err := ToTableMetadata(src, project, dataset, table, opts...)
+	if err != nil {
+		return nil, err
+	}
+
+	live, err := svc.Tables.Get(project, dataset, table).Context(ctx).Do()
+	var apiErr *googleapi.Error
+	if errors.As(err, &apiErr) && apiErr.Code == http.StatusNotFound {
+		return svc.Tables.Insert(project, dataset, t).Context(ctx).Do()
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	liveSchema := live.Schema
+	if liveSchema == nil {
+		liveSchema = &bigquery.TableSchema{}
+	}
+	diff := Diff(liveSchema, t.Schema)
+	if problems := incompatibleChanges(diff); len(problems) > 0 {
+		return nil, fmt.Errorf("table %s.%s.%s can not be updated: %s", project, dataset, table, strings.Join(problems, "; "))
+	}
+	// As BigQuery only adds nullable columns, added records get nullable fields.
+	for _, c := range diff.Added {
+		if field, err := GetField(t.Schema, c.Path); err == nil {
+			relaxFields(field.Fields)
+		}
+	}
+	if to, _ := TableOptionsOf(src); to.Expiration > 0 {
+		t.ExpirationTime = live.CreationTime + int64(to.Expiration/time.Millisecond)
+	}
+	if diff.Empty() && upToDate(live, t) {
+		return live, nil
+	}
+	return svc.Tables.Patch(project, dataset, table, t).Context(ctx).Do()
+}
+
+// upToDate reports whether the live table already has the options t would
+// patch it with. Options t leaves empty are not sent, so they never differ.
+func upToDate(live, t *bigquery.Table) bool {
+	if t.Description != "" && t.Description != live.Description {
+		return false
+	}
+	for k, v := range t.Labels {
+		if lv, ok := live.Labels[k]; !ok || lv != v {
+			return false
+		}
+	}
+	if t.ExpirationTime != 0 && t.ExpirationTime != live.ExpirationTime {
+		return false
+	}
+	return !t.RequirePartitionFilter || live.RequirePartitionFilter
+}
+
+// incompatibleChanges lists the changes of a diff from a live schema which
+// BigQuery does not allow on existing tables.
+func incompatibleChanges(d *SchemaDiff) []string {
+	var problems []string
+	for _, c := range d.Removed {
+		problems = append(problems, fmt.Sprintf("column %s removed", c.Path))
+	}
+	for _, c := range d.Added {
+		if canonicalMode(c.New.Mode) == "required" {
+			problems = append(problems, fmt.Sprintf("required column %s added", c.Path))
+		}
+	}
+	for _, c := range d.Changed {
+		if c.TypeChanged() {
+			problems = append(problems, fmt.Sprintf("type of %s changed from %s to %s", c.Path, canonicalType(c.Old.Type), canonicalType(c.New.Type)))
+		}
+		if c.ModeChanged() && (canonicalMode(c.Old.Mode) != "required" || canonicalMode(c.New.Mode) != "nullable") {
+			problems = append(problems, fmt.Sprintf("mode of %s changed from %s to %s", c.Path, canonicalMode(c.Old.Mode), canonicalMode(c.New.Mode)))
+		}
+	}
+	return problems
+}
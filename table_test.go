@@ -0,0 +1,196 @@
+package bqschema
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"time"
+
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+
+	"google.golang.org/api/bigquery/v2"
+	"google.golang.org/api/option"
+)
+
+type tableEvent struct {
+	ID   int64  `json:"id"`
+	Name string `json:"name,omitempty"`
+}
+
+func (tableEvent) BigQueryTableOptions() TableOptions {
+	return TableOptions{
+		Description:            "Client events",
+		Labels:                 map[string]string{"team": "growth"},
+		Expiration:             48 * time.Hour,
+		RequirePartitionFilter: true,
+	}
+}
+
+func (tableEvent) SchemaVersion() string { return "2" }
+
+type tablePointerEvent struct {
+	ID int64 `json:"id"`
+}
+
+func (*tablePointerEvent) BigQueryTableOptions() TableOptions {
+	return TableOptions{Description: "Pointer events"}
+}
+
+var _ = Describe("ToTableMetadata", func() {
+	It("should build the table definition declared by the type", func() {
+		before := time.Now()
+		table, err := ToTableMetadata(tableEvent{}, "p", "d", "events")
+		Expect(err).To(BeNil())
+		Expect(table.TableReference).To(Equal(&bigquery.TableReference{ProjectId: "p", DatasetId: "d", TableId: "events"}))
+		Expect(table.Schema).To(Equal(MustToSchema(tableEvent{})))
+		Expect(table.Description).To(Equal("Client events\n\nSchema version: 2"))
+		Expect(table.Labels).To(Equal(map[string]string{"team": "growth", VersionLabel: "2"}))
+		Expect(table.RequirePartitionFilter).To(BeTrue())
+		expires := time.Unix(0, table.ExpirationTime*int64(time.Millisecond))
+		Expect(expires).To(BeTemporally("~", before.Add(48*time.Hour), time.Minute))
+	})
+
+	It("should find options declared on pointers and default to none", func() {
+		table, err := ToTableMetadata(tablePointerEvent{}, "p", "d", "t")
+		Expect(err).To(BeNil())
+		Expect(table.Description).To(Equal("Pointer events"))
+
+		table, err = ToTableMetadata(struct{ A int }{}, "p", "d", "t")
+		Expect(err).To(BeNil())
+		Expect(table.Description).To(BeEmpty())
+		Expect(table.Labels).To(BeNil())
+		Expect(table.ExpirationTime).To(BeZero())
+	})
+})
+
+var _ = Describe("EnsureTable", func() {
+	var (
+		server  *httptest.Server
+		svc     *bigquery.Service
+		live    *bigquery.Table
+		methods []string
+		written *bigquery.Table
+	)
+
+	BeforeEach(func() {
+		live = nil
+		methods = nil
+		written = nil
+		server = httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			defer GinkgoRecover()
+			methods = append(methods, r.Method+" "+r.URL.Path)
+			switch r.Method {
+			case http.MethodGet:
+				if live == nil {
+					w.WriteHeader(http.StatusNotFound)
+					json.NewEncoder(w).Encode(map[string]interface{}{"error": map[string]interface{}{"code": 404, "message": "not found"}})
+					return
+				}
+				json.NewEncoder(w).Encode(live)
+			default:
+				written = &bigquery.Table{}
+				Expect(json.NewDecoder(r.Body).Decode(written)).To(Succeed())
+				json.NewEncoder(w).Encode(written)
+			}
+		}))
+		var err error
+		svc, err = bigquery.NewService(context.Background(), option.WithEndpoint(server.URL), option.WithoutAuthentication())
+		Expect(err).To(BeNil())
+	})
+
+	AfterEach(func() {
+		server.Close()
+	})
+
+	It("should create missing tables", func() {
+		table, err := EnsureTable(context.Background(), svc, "p", "d", "events", tableEvent{})
+		Expect(err).To(BeNil())
+		Expect(methods).To(Equal([]string{"GET /projects/p/datasets/d/tables/events", "POST /projects/p/datasets/d/tables"}))
+		Expect(table.Description).To(Equal("Client events\n\nSchema version: 2"))
+		Expect(written.Schema.Fields).To(HaveLen(2))
+	})
+
+	It("should update existing tables with compatible changes", func() {
+		live = &bigquery.Table{
+			CreationTime: 1000,
+			Schema: &bigquery.TableSchema{Fields: []*bigquery.TableFieldSchema{
+				&bigquery.TableFieldSchema{Mode: "REQUIRED", Name: "id", Type: "INTEGER"},
+			}},
+		}
+		_, err := EnsureTable(context.Background(), svc, "p", "d", "events", tableEvent{})
+		Expect(err).To(BeNil())
+		Expect(methods).To(Equal([]string{"GET /projects/p/datasets/d/tables/events", "PATCH /projects/p/datasets/d/tables/events"}))
+		Expect(written.Schema.Fields[1].Name).To(Equal("name"))
+		Expect(written.Labels).To(HaveKeyWithValue("team", "growth"))
+		Expect(written.ExpirationTime).To(BeNumerically("==", 1000+48*60*60*1000))
+	})
+
+	It("should add records with nullable fields", func() {
+		type address struct {
+			City string `json:"city"`
+		}
+		live = &bigquery.Table{
+			Schema: &bigquery.TableSchema{Fields: []*bigquery.TableFieldSchema{
+				&bigquery.TableFieldSchema{Mode: "REQUIRED", Name: "id", Type: "INTEGER"},
+			}},
+		}
+		_, err := EnsureTable(context.Background(), svc, "p", "d", "users", struct {
+			ID      int64    `json:"id"`
+			Address *address `json:"address"`
+		}{})
+		Expect(err).To(BeNil())
+		Expect(written.Schema.Fields[1]).To(Equal(&bigquery.TableFieldSchema{
+			Mode: "nullable", Name: "address", Type: "record", Fields: []*bigquery.TableFieldSchema{
+				&bigquery.TableFieldSchema{Mode: "nullable", Name: "city", Type: "string"},
+			},
+		}))
+	})
+
+	It("should not update tables already up to date", func() {
+		upToDate := func() *bigquery.Table {
+			return &bigquery.Table{
+				CreationTime:           1000,
+				Description:            "Client events\n\nSchema version: 2",
+				Labels:                 map[string]string{"team": "growth", VersionLabel: "2", "owner": "ops"},
+				ExpirationTime:         1000 + 48*60*60*1000,
+				RequirePartitionFilter: true,
+				Schema: &bigquery.TableSchema{Fields: []*bigquery.TableFieldSchema{
+					&bigquery.TableFieldSchema{Mode: "REQUIRED", Name: "id", Type: "INTEGER"},
+					&bigquery.TableFieldSchema{Mode: "NULLABLE", Name: "name", Type: "STRING"},
+				}},
+			}
+		}
+		live = upToDate()
+		table, err := EnsureTable(context.Background(), svc, "p", "d", "events", tableEvent{})
+		Expect(err).To(BeNil())
+		Expect(methods).To(Equal([]string{"GET /projects/p/datasets/d/tables/events"}))
+		Expect(table.Labels).To(HaveKeyWithValue("owner", "ops"))
+
+		methods = nil
+		live = upToDate()
+		live.Labels["team"] = "web"
+		_, err = EnsureTable(context.Background(), svc, "p", "d", "events", tableEvent{})
+		Expect(err).To(BeNil())
+		Expect(methods).To(Equal([]string{"GET /projects/p/datasets/d/tables/events", "PATCH /projects/p/datasets/d/tables/events"}))
+	})
+
+	It("should convert with options", func() {
+		_, err := EnsureTable(context.Background(), svc, "p", "d", "events", tableEvent{}, WithDefaultMode(Nullable))
+		Expect(err).To(BeNil())
+		Expect(written.Schema.Fields[0].Mode).To(Equal("nullable"))
+	})
+
+	It("should not update tables with incompatible changes", func() {
+		live = &bigquery.Table{
+			Schema: &bigquery.TableSchema{Fields: []*bigquery.TableFieldSchema{
+				&bigquery.TableFieldSchema{Mode: "NULLABLE", Name: "id", Type: "STRING"},
+				&bigquery.TableFieldSchema{Mode: "NULLABLE", Name: "gone", Type: "STRING"},
+			}},
+		}
+		_, err := EnsureTable(context.Background(), svc, "p", "d", "events", tableEvent{})
+		Expect(err).To(MatchError("table p.d.events can not be updated: column gone removed; type of id changed from string to integer; mode of id changed from nullable to required"))
+		Expect(methods).To(Equal([]string{"GET /projects/p/datasets/d/tables/events"}))
+	})
+})
@@ -0,0 +1,296 @@
+package bqschema
+
+import (
+	"reflect"
+	"strings"
+
+	"google.golang.org/api/bigquery/v2"
+)
+
+// fieldTag is the column naming and options declared by a struct field's tags.
+//
+// The json tag supplies the name, "-" to skip the field and omitempty or
+// omitzero to make it nullable, as it does for encoding/json. Other options
+// must be declared with RegisterJSONOption. The bigquery tag overrides the name
+// and takes comma separated attributes:
+//
+//	Shape  Shape  `bigquery:"shape,as=Circle"`
+//	Period string `bigquery:",type=RANGE<DATE>"`
+//	Name   string `bigquery:",collation=und:ci"`
+//	Price  string `bigquery:",type=NUMERIC,roundingMode=ROUND_HALF_EVEN"`
+//
+// The description tag sets the description of the column and the default tag
+// its default value expression:
+//
+//	City    string    `description:"City of the billing address."`
+//	Created time.Time `default:"CURRENT_TIMESTAMP()"`
+//
+// The redact tag declares how values are redacted before they are inserted,
+// as a SHA-256 digest or truncated to a number of characters, and is noted in
+// the description of the column:
+//
+//	Email string `redact:"sha256"`
+//	Phone string `redact:"truncate=4"`
+//
+// Columns can be named by the tags of other packages instead, such as the db
+// tags of database/sql helpers, the column settings of gorm tags or the name
+// attributes of parquet tags, with WithTagPriority:
+//
+//	UserID int64  `db:"user_id"`
+//	Email  string `gorm:"column:email_address;unique"`
+//
+// Unexported fields are skipped unless tagged `bqschema:"export"`. Their values
+// are read with an accessor method named after the field, so a score field is
+// read by calling Score().
+type fieldTag struct {
+	name     string
+	skip     bool
+	nullable bool
+	attrs    map[string]string
+	accessor string   // method reading an exported unexported field
+	unknown  []string // undeclared json options
+
+	description  string
+	defaultValue string
+	redact       string
+}
+
+func parseFieldTag(sf reflect.StructField) fieldTag {
+	return parseFieldTagIn(sf, defaultTagPriority)
+}
+
+// parseFieldTagIn parses the tags of sf, naming the column after the first of
+// the tags listed in priority that names it. The field is skipped if a tag
+// says so before any names it; options of all the tags apply.
+func parseFieldTagIn(sf reflect.StructField, priority []string) fieldTag {
+	tag := fieldTag{name: sf.Name, description: sf.Tag.Get("description"), defaultValue: sf.Tag.Get("default"), redact: sf.Tag.Get("redact")}
+
+	if sf.PkgPath != "" {
+		if !hasOption(sf.Tag.Get("bqschema"), "export") {
+			tag.skip = true
+			return tag
+		}
+		tag.accessor = strings.ToUpper(sf.Name[:1]) + sf.Name[1:]
+	}
+
+	named := false
+	for _, key := range priority {
+		value := sf.Tag.Get(key)
+		if value == "" || (value == "-" && named) {
+			continue
+		}
+		if value == "-" {
+			tag.skip = true
+			return tag
+		}
+
+		name := tag.name
+		tag.name = ""
+		switch key {
+		case "json":
+			tag.parseJSON(value)
+		case "bigquery":
+			tag.parseBigQuery(value)
+		case "gorm":
+			tag.parseGorm(value)
+		default:
+			tag.parseName(value)
+		}
+		if tag.skip && !named {
+			return tag
+		}
+		tag.skip = false
+		if tag.name == "" || named {
+			tag.name = name
+		} else {
+			named = true
+		}
+	}
+	return tag
+}
+
+func (tag *fieldTag) parseJSON(value string) {
+	jt := strings.Split(value, ",")
+	if jt[0] != "" {
+		tag.name = jt[0]
+	}
+	for _, opt := range jt[1:] {
+		nullable, ok := jsonOption(opt)
+		if !ok && opt != "" {
+			tag.unknown = append(tag.unknown, opt)
+		}
+		if nullable {
+			tag.nullable = true
+		}
+	}
+}
+
+func (tag *fieldTag) parseBigQuery(value string) {
+	bt := strings.Split(value, ",")
+	if bt[0] != "" {
+		tag.name = bt[0]
+	}
+	tag.attrs = make(map[string]string, len(bt)-1)
+	for _, attr := range bt[1:] {
+		kv := strings.SplitN(attr, "=", 2)
+		if len(kv) == 2 {
+			tag.attrs[kv[0]] = kv[1]
+		} else {
+			tag.attrs[kv[0]] = ""
+		}
+	}
+}
+
+// parseGorm reads the column setting of a gorm tag, such as
+// `gorm:"column:user_id;not null"`, and skips fields gorm ignores.
+func (tag *fieldTag) parseGorm(value string) {
+	for _, setting := range strings.Split(value, ";") {
+		setting = strings.TrimSpace(setting)
+		switch {
+		case strings.HasPrefix(setting, "-"):
+			tag.skip = true
+		case strings.HasPrefix(setting, "column:"):
+			tag.name = strings.TrimPrefix(setting, "column:")
+		}
+	}
+}
+
+// parseName reads the name of a tag of another package, either its first comma
+// separated element, as in `db:"user_id"`, or a name attribute, as in
+// `parquet:"name=user_id, type=INT64"`. Options making json fields nullable,
+// such as omitempty, make its column nullable too.
+func (tag *fieldTag) parseName(value string) {
+	parts := strings.Split(value, ",")
+	for i, part := range parts {
+		part = strings.TrimSpace(part)
+		switch {
+		case strings.HasPrefix(part, "name="):
+			tag.name = strings.TrimPrefix(part, "name=")
+		case i == 0 && part != "" && !strings.Contains(part, "="):
+			tag.name = part
+		case i > 0:
+			if nullable, _ := jsonOption(part); nullable {
+				tag.nullable = true
+			}
+		}
+	}
+}
+
+func hasOption(tag, option string) bool {
+	for _, opt := range strings.Split(tag, ",") {
+		if opt == option {
+			return true
+		}
+	}
+	return false
+}
+
+// checkAccessor verifies that the struct type t has the accessor method of an exported unexported field.
+func checkAccessor(t reflect.Type, sf reflect.StructField, tag fieldTag) error {
+	m, ok := reflect.PtrTo(t).MethodByName(tag.accessor)
+	if !ok || m.Type.NumIn() != 1 || m.Type.NumOut() != 1 || !m.Type.Out(0).AssignableTo(sf.Type) {
+		return fieldError(KindTag, "exported field of %s requires a method %s() %s", t, tag.accessor, sf.Type)
+	}
+	return nil
+}
+
+// accessorValue calls the accessor method of an exported unexported field of the struct v.
+func accessorValue(v reflect.Value, tag fieldTag) reflect.Value {
+	if !v.CanAddr() {
+		c := reflect.New(v.Type()).Elem()
+		c.Set(v)
+		v = c
+	}
+	return v.Addr().MethodByName(tag.accessor).Call(nil)[0]
+}
+
+// taggedField returns the field declared by a type attribute, which overrides
+// the column type of a field or, for arrays, of its elements. RANGE types name
+// their element type in angle brackets: type=RANGE<DATE>.
+func taggedField(tag fieldTag, name, mode string) (*bigquery.TableFieldSchema, bool, error) {
+	t, ok := tag.attrs["type"]
+	if !ok {
+		return nil, false, nil
+	}
+	tfs := &bigquery.TableFieldSchema{
+		Mode: mode,
+		Name: name,
+		Type: strings.ToLower(t),
+	}
+
+	if i := strings.Index(tfs.Type, "<"); i >= 0 {
+		if tfs.Type[:i] != "range" || !strings.HasSuffix(tfs.Type, ">") {
+			return nil, false, fieldError(KindTag, "invalid type %q", t)
+		}
+		element := tfs.Type[i+1 : len(tfs.Type)-1]
+		switch element {
+		case "date", "datetime", "timestamp":
+		default:
+			return nil, false, fieldError(KindTag, "invalid range element type %q", element)
+		}
+		tfs.Type = "range"
+		tfs.RangeElementType = &bigquery.TableFieldSchemaRangeElementType{Type: element}
+	} else if tfs.Type == "range" {
+		return nil, false, fieldError(KindTag, "range type requires an element type")
+	}
+	return tfs, true, nil
+}
+
+// ApplyTags sets the column options declared by the tags of a struct field on
+// tfs, the column converted from the field, checking them as ToSchema does:
+// the roundingMode and collation attributes of the bigquery tag and the
+// default, description and redact tags. A description already set on tfs
+// follows the one of the description tag. It lets code generators honor the
+// tags read by ToSchema.
+func ApplyTags(tfs *bigquery.TableFieldSchema, tag reflect.StructTag) error {
+	return applyAttributes(tfs, parseFieldTag(reflect.StructField{Name: tfs.Name, Tag: tag}))
+}
+
+// applyAttributes sets the column options declared by the bigquery tag on a converted field.
+func applyAttributes(tfs *bigquery.TableFieldSchema, tag fieldTag) error {
+	if tag.redact != "" {
+		if err := applyRedaction(tfs, tag.redact); err != nil {
+			return err
+		}
+	}
+
+	if mode, ok := tag.attrs["roundingMode"]; ok {
+		switch t := canonicalType(tfs.Type); t {
+		case "numeric", "bignumeric":
+		default:
+			return fieldError(KindTag, "rounding mode not allowed for %s column", t)
+		}
+		switch mode = strings.ToUpper(mode); mode {
+		case "ROUND_HALF_AWAY_FROM_ZERO", "ROUND_HALF_EVEN":
+			tfs.RoundingMode = mode
+		default:
+			return fieldError(KindTag, "invalid rounding mode %q", mode)
+		}
+	}
+
+	if collation, ok := tag.attrs["collation"]; ok {
+		if t := canonicalType(tfs.Type); t != "string" {
+			return fieldError(KindTag, "collation not allowed for %s column", t)
+		}
+		tfs.Collation = collation
+	}
+
+	if tag.defaultValue != "" {
+		if err := checkDefault(tag.defaultValue, tfs.Type, tfs.Mode); err != nil {
+			return err
+		}
+		tfs.DefaultValueExpression = tag.defaultValue
+	}
+
+	if tag.description != "" {
+		if tfs.Description != "" {
+			tfs.Description = tag.description + " " + tfs.Description
+		} else {
+			tfs.Description = tag.description
+		}
+	}
+	if tag.redact != "" {
+		tfs.Description = strings.TrimSpace(tfs.Description + " " + redactionNote(tag.redact))
+	}
+	return nil
+}
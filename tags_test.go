@@ -0,0 +1,284 @@
+package bqschema
+
+import (
+	"reflect"
+	"time"
+
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+
+	"google.golang.org/api/bigquery/v2"
+)
+
+var _ = Describe("parseFieldTag", func() {
+	type tagged struct {
+		Plain    string
+		JSON     string `json:"json,omitempty"`
+		Options  string `json:",omitempty"`
+		Zero     string `json:",omitzero,string"`
+		Future   string `json:",omitnil"`
+		Skipped  string `json:"-"`
+		BigQuery string `json:"other" bigquery:"bq,as=circle,flag"`
+		BQSkip   string `bigquery:"-"`
+		hidden   string
+		exported string `bqschema:"export" bigquery:"kept"`
+	}
+	t := reflect.TypeOf(tagged{})
+
+	table := [][]interface{}{
+		[]interface{}{"Plain", fieldTag{name: "Plain"}},
+		[]interface{}{"JSON", fieldTag{name: "json", nullable: true}},
+		[]interface{}{"Options", fieldTag{name: "Options", nullable: true}},
+		[]interface{}{"Zero", fieldTag{name: "Zero", nullable: true}},
+		[]interface{}{"Future", fieldTag{name: "Future", unknown: []string{"omitnil"}}},
+		[]interface{}{"Skipped", fieldTag{name: "Skipped", skip: true}},
+		[]interface{}{"BigQuery", fieldTag{name: "bq", attrs: map[string]string{"as": "circle", "flag": ""}}},
+		[]interface{}{"BQSkip", fieldTag{name: "BQSkip", skip: true}},
+		[]interface{}{"hidden", fieldTag{name: "hidden", skip: true}},
+		[]interface{}{"exported", fieldTag{name: "kept", attrs: map[string]string{}, accessor: "Exported"}},
+	}
+
+	for _, data := range table {
+		name := data[0].(string)
+		expected := data[1].(fieldTag)
+		It("should parse the tags of field "+name, func() {
+			sf, _ := t.FieldByName(name)
+			Expect(parseFieldTag(sf)).To(Equal(expected))
+		})
+	}
+})
+
+var _ = Describe("Tag priority", func() {
+	type user struct {
+		ID      int64  `db:"user_id" json:"id"`
+		Email   string `gorm:"column:email_address;unique" json:"email"`
+		Name    string `bigquery:"full_name" db:"name"`
+		Nick    string `parquet:"name=nick_name, type=BYTE_ARRAY" db:"nickname,omitempty"`
+		Secret  string `db:"-"`
+		Ignored string `gorm:"-:all"`
+	}
+	t := reflect.TypeOf(user{})
+
+	It("should name columns after the first listed tag naming them", func() {
+		table := [][]interface{}{
+			[]interface{}{[]string{"bigquery", "json"}, []string{"id", "email", "full_name", "Nick", "Secret", "Ignored"}},
+			[]interface{}{[]string{"db", "json"}, []string{"user_id", "email", "name", "nickname", "-", "Ignored"}},
+			[]interface{}{[]string{"bigquery", "gorm", "db"}, []string{"user_id", "email_address", "full_name", "nickname", "-", "-"}},
+			[]interface{}{[]string{"parquet", "db"}, []string{"user_id", "Email", "name", "nick_name", "-", "Ignored"}},
+		}
+		for _, data := range table {
+			priority := data[0].([]string)
+			names := make([]string, t.NumField())
+			for i := range names {
+				tag := parseFieldTagIn(t.Field(i), priority)
+				if names[i] = tag.name; tag.skip {
+					names[i] = "-"
+				}
+			}
+			Expect(names).To(Equal(data[1]), "%v", priority)
+		}
+		nick, _ := t.FieldByName("Nick")
+		Expect(parseFieldTagIn(nick, []string{"db"}).nullable).To(BeTrue())
+	})
+
+	It("should convert schemas with the scoped priority", func() {
+		schema, err := ToSchema(user{}, WithTagPriority("db", "json"))
+		Expect(err).To(BeNil())
+		Expect(schema.Fields).To(Equal([]*bigquery.TableFieldSchema{
+			&bigquery.TableFieldSchema{Mode: "required", Name: "user_id", Type: "integer"},
+			&bigquery.TableFieldSchema{Mode: "required", Name: "email", Type: "string"},
+			&bigquery.TableFieldSchema{Mode: "required", Name: "name", Type: "string"},
+			&bigquery.TableFieldSchema{Mode: "nullable", Name: "nickname", Type: "string"},
+			&bigquery.TableFieldSchema{Mode: "required", Name: "Ignored", Type: "string"},
+		}))
+		Expect(MustToSchema(user{}).Fields[0].Name).To(Equal("id"))
+	})
+
+	It("should not skip fields named by a tag of higher priority", func() {
+		type legacy struct {
+			Old string `db:"old" json:"-"`
+			New string `db:"-" json:"new"`
+		}
+		lt := reflect.TypeOf(legacy{})
+		Expect(parseFieldTagIn(lt.Field(0), []string{"db", "json"})).To(Equal(fieldTag{name: "old"}))
+		Expect(parseFieldTagIn(lt.Field(0), []string{"json", "db"}).skip).To(BeTrue())
+		Expect(parseFieldTagIn(lt.Field(1), []string{"db", "json"}).skip).To(BeTrue())
+		Expect(parseFieldTagIn(lt.Field(1), []string{"json", "db"}).name).To(Equal("new"))
+	})
+
+	It("should encode and decode rows with the scoped priority", func() {
+		o := newOptions([]Option{WithTagPriority("db", "json")})
+		Expect(o.structToRow(reflect.ValueOf(user{ID: 1, Email: "a@b.c"}))).To(Equal(map[string]interface{}{
+			"user_id":  int64(1),
+			"email":    "a@b.c",
+			"name":     "",
+			"nickname": "",
+			"Ignored":  "",
+		}))
+
+		schema := MustToSchema(user{}, WithTagPriority("db", "json"))
+		rows := []*bigquery.TableRow{{F: []*bigquery.TableCell{{V: "1"}, {V: "a@b.c"}, {V: "Ann"}, {V: nil}, {V: ""}}}}
+		var users []user
+		Expect(RowsToStructs(schema, rows, &users, WithTagPriority("db", "json"))).To(Succeed())
+		Expect(users).To(Equal([]user{{ID: 1, Email: "a@b.c", Name: "Ann"}}))
+	})
+})
+
+var _ = Describe("Type attributes", func() {
+	type row struct {
+		Period  string    `bigquery:",type=RANGE<DATE>"`
+		Periods []string  `json:"periods" bigquery:",type=range<timestamp>"`
+		Amount  string    `bigquery:",type=NUMERIC"`
+		Blob    []byte    `json:",omitempty" bigquery:",type=bytes"`
+		Day     time.Time `bigquery:",type=date"`
+	}
+
+	It("should override the column type", func() {
+		schema, err := ToSchema(row{})
+		Expect(err).To(BeNil())
+		Expect(schema.Fields).To(Equal([]*bigquery.TableFieldSchema{
+			&bigquery.TableFieldSchema{
+				Mode:             "required",
+				Name:             "Period",
+				Type:             "range",
+				RangeElementType: &bigquery.TableFieldSchemaRangeElementType{Type: "date"},
+			},
+			&bigquery.TableFieldSchema{
+				Mode:             "repeated",
+				Name:             "periods",
+				Type:             "range",
+				RangeElementType: &bigquery.TableFieldSchemaRangeElementType{Type: "timestamp"},
+			},
+			&bigquery.TableFieldSchema{Mode: "required", Name: "Amount", Type: "numeric"},
+			&bigquery.TableFieldSchema{Mode: "nullable", Name: "Blob", Type: "bytes"},
+			&bigquery.TableFieldSchema{Mode: "required", Name: "Day", Type: "date"},
+		}))
+	})
+
+	It("should reject invalid range types", func() {
+		_, err := ToSchema(struct {
+			A string `bigquery:",type=range"`
+		}{})
+		Expect(err).To(MatchError("A: range type requires an element type"))
+		_, err = ToSchema(struct {
+			A string `bigquery:",type=range<int64>"`
+		}{})
+		Expect(err).To(MatchError(`A: invalid range element type "int64"`))
+		_, err = ToSchema(struct {
+			A string `bigquery:",type=array<int64>"`
+		}{})
+		Expect(err).To(MatchError(`A: invalid type "array<int64>"`))
+	})
+})
+
+var _ = Describe("Column option attributes", func() {
+	type row struct {
+		Name   string   `bigquery:",collation=und:ci"`
+		Tags   []string `bigquery:",collation=und:ci"`
+		Price  string   `bigquery:",type=NUMERIC,roundingMode=round_half_even"`
+		Amount string   `bigquery:",type=bignumeric,roundingMode=ROUND_HALF_AWAY_FROM_ZERO"`
+	}
+
+	It("should set rounding modes and collations", func() {
+		schema, err := ToSchema(row{})
+		Expect(err).To(BeNil())
+		Expect(schema.Fields).To(Equal([]*bigquery.TableFieldSchema{
+			&bigquery.TableFieldSchema{Mode: "required", Name: "Name", Type: "string", Collation: "und:ci"},
+			&bigquery.TableFieldSchema{Mode: "repeated", Name: "Tags", Type: "string", Collation: "und:ci"},
+			&bigquery.TableFieldSchema{Mode: "required", Name: "Price", Type: "numeric", RoundingMode: "ROUND_HALF_EVEN"},
+			&bigquery.TableFieldSchema{Mode: "required", Name: "Amount", Type: "bignumeric", RoundingMode: "ROUND_HALF_AWAY_FROM_ZERO"},
+		}))
+	})
+
+	It("should set descriptions", func() {
+		schema, err := ToSchema(struct {
+			Name   string `description:"Display name."`
+			Status string `bigquery:",enum=on|off" description:"Account status."`
+		}{})
+		Expect(err).To(BeNil())
+		Expect(schema.Fields[0].Description).To(Equal("Display name."))
+		Expect(schema.Fields[1].Description).To(Equal("Account status. One of: on, off."))
+	})
+
+	It("should reject options that do not apply to the column", func() {
+		_, err := ToSchema(struct {
+			A float64 `bigquery:",roundingMode=ROUND_HALF_EVEN"`
+		}{})
+		Expect(err).To(MatchError("A: rounding mode not allowed for float column"))
+		_, err = ToSchema(struct {
+			A string `bigquery:",type=numeric,roundingMode=ROUND_UP"`
+		}{})
+		Expect(err).To(MatchError(`A: invalid rounding mode "ROUND_UP"`))
+		_, err = ToSchema(struct {
+			A int `bigquery:",collation=und:ci"`
+		}{})
+		Expect(err).To(MatchError("A: collation not allowed for integer column"))
+	})
+})
+
+type exportedRow struct {
+	ID    int
+	score float64 `bqschema:"export"`
+}
+
+func (r *exportedRow) Score() float64 { return r.score }
+
+type missingAccessor struct {
+	count int `bqschema:"export"`
+}
+
+var _ = Describe("Exported unexported fields", func() {
+	It("should convert fields tagged export", func() {
+		schema, err := ToSchema(exportedRow{})
+		Expect(err).To(BeNil())
+		Expect(schema.Fields).To(Equal([]*bigquery.TableFieldSchema{
+			&bigquery.TableFieldSchema{Mode: "required", Name: "ID", Type: "integer"},
+			&bigquery.TableFieldSchema{Mode: "required", Name: "score", Type: "float"},
+		}))
+	})
+
+	It("should read their values with the accessor method", func() {
+		Expect(structToRow(reflect.ValueOf(exportedRow{ID: 1, score: 2.5}))).To(Equal(map[string]interface{}{
+			"ID":    1,
+			"score": 2.5,
+		}))
+	})
+
+	It("should require an accessor method", func() {
+		_, err := ToSchema(missingAccessor{})
+		Expect(err).To(MatchError("count: exported field of bqschema.missingAccessor requires a method Count() int"))
+	})
+})
+
+var _ = Describe("JSON options", func() {
+	type row struct {
+		At   time.Time `json:"at,omitzero"`
+		Note string    `json:"note,omitnil"`
+	}
+
+	AfterEach(func() {
+		registryMu.Lock()
+		delete(jsonOptions, "omitnil")
+		registryGen++
+		registryMu.Unlock()
+	})
+
+	It("should make fields tagged omitzero nullable", func() {
+		Expect(MustToSchema(row{}).Fields[0].Mode).To(Equal("nullable"))
+	})
+
+	It("should report undeclared options and reject them in strict mode", func() {
+		_, report, err := ToSchemaWithReport(row{})
+		Expect(err).To(BeNil())
+		Expect(report.Warnings()[0].String()).To(Equal(`note: unknown json option "omitnil" ignored`))
+		_, err = ToSchema(row{}, WithStrict())
+		Expect(err).To(MatchError(`note: unknown json option "omitnil"`))
+	})
+
+	It("should follow declared options", func() {
+		RegisterJSONOption("omitnil", true)
+		schema, err := ToSchema(row{}, WithStrict())
+		Expect(err).To(BeNil())
+		Expect(schema.Fields[1].Mode).To(Equal("nullable"))
+	})
+})
@@ -0,0 +1,163 @@
+package bqschema
+
+import (
+	"fmt"
+	"reflect"
+	"strconv"
+	"time"
+)
+
+// SchemaMarshaler is implemented by types encoding themselves into the cells
+// of rows, the counterpart of SchemaUnmarshaler for types mapped to columns
+// with RegisterTypeMapping or WithTypeMapping, such as a money type mapped to
+// NUMERIC. MarshalBigQuery returns the value of the cell as CoerceRow accepts
+// it: a string, number, bool or time.Time for scalars, a slice for repeated
+// columns and a map keyed by column name for records, or nil for NULL.
+// The errors it returns are wrapped with the path of the column.
+type SchemaMarshaler interface {
+	MarshalBigQuery() (interface{}, error)
+}
+
+var schemaMarshalerType = reflect.TypeOf((*SchemaMarshaler)(nil)).Elem()
+
+// structToRow converts a struct value into a row keyed by the same column names ToSchema uses.
+func structToRow(v reflect.Value) (map[string]interface{}, error) {
+	return (*options)(nil).structToRow(v)
+}
+
+// structToRow converts a struct value into a row keyed by the same column
+// names ToSchema uses with options o, encoding the string types of o as strings.
+// Errors are FieldErrors of kind KindEncode for the column that failed.
+func (o *options) structToRow(v reflect.Value) (map[string]interface{}, error) {
+	t := v.Type()
+	row := make(map[string]interface{}, t.NumField())
+	for _, fp := range o.plan(t).fields {
+		tag := fp.tag
+		if tag.skip {
+			continue
+		}
+		fv := v.Field(fp.index)
+		if tag.accessor != "" {
+			fv = accessorValue(v, tag)
+		}
+		value, err := o.rowValue(fv)
+		if err != nil {
+			return nil, wrapFieldError(tag.name, err)
+		}
+		if tag.redact != "" {
+			value = redactValue(tag.redact, value)
+		}
+		row[tag.name] = value
+	}
+	return row, nil
+}
+
+// rowEncoder returns the func encoding values of the struct type t into rows
+// of its schema converted with o: rows of structToRow, flattened if the
+// schema is.
+func (o *options) rowEncoder(t reflect.Type) (func(v reflect.Value) (map[string]interface{}, error), error) {
+	if !o.flatten {
+		return o.structToRow, nil
+	}
+	nested, err := toSchema(reflect.Zero(t).Interface(), o)
+	if err != nil {
+		return nil, err
+	}
+	return func(v reflect.Value) (map[string]interface{}, error) {
+		row, err := o.structToRow(v)
+		if err != nil {
+			return nil, err
+		}
+		return flattenRow(nested.Fields, row, 0, o), nil
+	}, nil
+}
+
+func (o *options) rowValue(v reflect.Value) (interface{}, error) {
+	v = indirectValue(v)
+	if !v.IsValid() {
+		return nil, nil
+	}
+
+	if m, ok := marshaler(v); ok {
+		value, err := m.MarshalBigQuery()
+		if err != nil {
+			return nil, &FieldError{Kind: KindEncode, Wrapped: err}
+		}
+		return value, nil
+	}
+	if o.plan(v.Type()).stringType {
+		return stringValue(v), nil
+	}
+
+	switch v.Kind() {
+	case reflect.Struct:
+		if v.Type().ConvertibleTo(reflect.TypeOf(time.Time{})) {
+			return v.Interface(), nil
+		}
+		return o.structToRow(v)
+	case reflect.Slice, reflect.Array:
+		if v.Kind() == reflect.Slice && v.IsNil() {
+			return nil, nil
+		}
+		elem := v.Type().Elem()
+		if _, isSimple := simpleType(elem.Kind()); isSimple && !o.plan(elem).stringType && !reflect.PtrTo(elem).Implements(schemaMarshalerType) {
+			return v.Interface(), nil
+		}
+		values := make([]interface{}, 0, v.Len())
+		for i := 0; i < v.Len(); i++ {
+			value, err := o.rowValue(v.Index(i))
+			if err != nil {
+				return nil, err
+			}
+			// BigQuery arrays can not hold null, so nil elements are dropped.
+			if value != nil {
+				values = append(values, value)
+			}
+		}
+		return values, nil
+	case reflect.Map:
+		if v.IsNil() {
+			return nil, nil
+		}
+		return o.mapEntries(v)
+	default:
+		return v.Interface(), nil
+	}
+}
+
+// marshaler returns v as a SchemaMarshaler, through a pointer or not.
+func marshaler(v reflect.Value) (SchemaMarshaler, bool) {
+	if !reflect.PtrTo(v.Type()).Implements(schemaMarshalerType) {
+		return nil, false
+	}
+	if m, ok := v.Interface().(SchemaMarshaler); ok {
+		return m, true
+	}
+	c := reflect.New(v.Type())
+	c.Elem().Set(v)
+	return c.Interface().(SchemaMarshaler), true
+}
+
+// stringValue encodes a value of a string type with String(), or its
+// underlying value for types of scalar kinds without a String method.
+func stringValue(v reflect.Value) string {
+	if s, ok := v.Interface().(fmt.Stringer); ok {
+		return s.String()
+	}
+	c := reflect.New(v.Type())
+	c.Elem().Set(v)
+	if s, ok := c.Interface().(fmt.Stringer); ok {
+		return s.String()
+	}
+	switch v.Kind() {
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64:
+		return strconv.FormatInt(v.Int(), 10)
+	case reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64:
+		return strconv.FormatUint(v.Uint(), 10)
+	case reflect.Float32, reflect.Float64:
+		return strconv.FormatFloat(v.Float(), 'g', -1, v.Type().Bits())
+	case reflect.Bool:
+		return strconv.FormatBool(v.Bool())
+	}
+	return v.String()
+}
@@ -22,7 +22,19 @@ var (
 )
 
 // ToSchema converts the passed type to a BigQuery table schema.
-func ToSchema(src interface{}) (*bigquery.TableSchema, error) {
+// Slices convert to repeated columns of their element type, pointer elements
+// included; as BigQuery arrays can not hold null, nil elements are dropped
+// when rows are encoded. Maps with string or integer keys convert to repeated
+// records of key and value columns, encoded in key order.
+// Options are applied to this conversion only; use a Converter to reuse them.
+func ToSchema(src interface{}, opts ...Option) (*bigquery.TableSchema, error) {
+	if len(opts) == 0 {
+		return defaultConverter.ToSchema(src)
+	}
+	return NewConverter(opts...).ToSchema(src)
+}
+
+func toSchema(src interface{}, o *options) (*bigquery.TableSchema, error) {
 	value := reflect.ValueOf(src)
 	t := value.Type()
 
@@ -31,87 +43,246 @@ func ToSchema(src interface{}) (*bigquery.TableSchema, error) {
 	if t.Kind() != reflect.Struct {
 		return schema, ErrNotStruct
 	}
+	if o.visiting == nil {
+		c := *o
+		c.visiting = map[reflect.Type]bool{}
+		o = &c
+	}
+	leave, err := o.enterType(t)
+	if err != nil {
+		return schema, err
+	}
+	defer leave()
+	if o.maxDepth > 0 && len(o.visiting)-1 > o.maxDepth {
+		return schema, fieldError(KindLimit, "records nested deeper than %d levels", o.maxDepth)
+	}
+
 	schema.Fields = make([]*bigquery.TableFieldSchema, 0, t.NumField())
-	for i := 0; i < t.NumField(); i++ {
-		sf := t.Field(i)
-		if sf.PkgPath != "" { // unexported
+	for _, fp := range o.plan(t).fields {
+		sf, tag := fp.sf, fp.tag
+		if tag.skip {
+			o.report.enter(sf.Name)
+			if sf.PkgPath != "" {
+				if o.strict && sf.Tag.Get("json") != "-" && sf.Tag.Get("bigquery") != "-" {
+					return schema, wrapFieldError(sf.Name, fieldError(KindStrict, "unexported field skipped; tag it `bqschema:\"export\"` or `bigquery:\"-\"`"))
+				}
+				o.report.add(ReportSkipped, false, "unexported field")
+			} else {
+				o.report.add(ReportSkipped, false, "skipped by tag")
+			}
+			o.report.leave()
 			continue
 		}
 
-		v := pointerGuard(value.Field(i))
+		if o.columns++; o.maxFields > 0 && o.columns > o.maxFields {
+			return schema, wrapFieldError(tag.name, fieldError(KindLimit, "more than %d columns", o.maxFields))
+		}
+		o.report.enter(tag.name)
+		tfs, err := convertField(t, sf, value.Field(fp.index), tag, o)
+		o.report.leave()
+		if err != nil {
+			return schema, wrapFieldError(tag.name, err)
+		}
+		schema.Fields = append(schema.Fields, tfs)
+	}
+	return schema, nil
+}
+
+// convertField converts the field sf of the struct type t, holding fv.
+func convertField(t reflect.Type, sf reflect.StructField, fv reflect.Value, tag fieldTag, o *options) (*bigquery.TableFieldSchema, error) {
+	if tag.accessor != "" {
+		if err := checkAccessor(t, sf, tag); err != nil {
+			return nil, err
+		}
+		fv = reflect.New(sf.Type).Elem()
+	}
+	if tag.name != sf.Name {
+		o.report.add(ReportRenamed, false, "renamed from %s", sf.Name)
+	}
+	for _, opt := range tag.unknown {
+		if o.strict {
+			return nil, fieldError(KindStrict, "unknown json option %q", opt)
+		}
+		o.report.add(ReportMode, true, "unknown json option %q ignored", opt)
+	}
+
+	tfs, err := fieldSchema(sf, fv, tag, o)
+	if err != nil {
+		return nil, err
+	}
+	if err := applyAttributes(tfs, tag); err != nil {
+		return nil, err
+	}
 
-		name := sf.Name
-		mode := "required"
+	if l, ok := LogicalTypeOf(tfs); ok && l.AvroLogicalType != "" && o.report != nil {
+		if l.Precision != 0 {
+			o.report.add(ReportLogical, false, "%s; precision %d, scale %d", l, l.Precision, l.Scale)
+		} else {
+			o.report.add(ReportLogical, false, "%s", l)
+		}
+	}
 
-		switch jsonTag := sf.Tag.Get("json"); jsonTag {
-		case "":
-		case "-":
-			continue
-		default:
-			jt := strings.Split(jsonTag, ",")
-			name = jt[0]
-			if len(jt) > 1 && jt[1] == "omitempty" {
-				mode = "nullable"
-			}
+	switch {
+	case tfs.Mode == string(o.defaultMode):
+		if tfs.Mode == "required" && sf.Type.Kind() == reflect.Ptr {
+			o.report.add(ReportMode, true, "pointer field is required; nil values will be rejected")
 		}
+	case tag.nullable && tfs.Mode == "nullable":
+		o.report.add(ReportMode, false, "nullable as tagged omitempty")
+	case tfs.Mode == "repeated":
+		o.report.add(ReportMode, false, "repeated for %s", sf.Type)
+	case tfs.Mode == "nullable" && tfs.Type == "record":
+		o.report.add(ReportMode, false, "nullable as a record")
+	default:
+		o.report.add(ReportMode, false, "%s mode", tfs.Mode)
+	}
+	return tfs, nil
+}
 
-		kind := v.Kind()
-		t, isSimple := simpleType(kind)
+// fieldSchema converts a single struct field holding fv.
+func fieldSchema(sf reflect.StructField, fv reflect.Value, tag fieldTag, o *options) (*bigquery.TableFieldSchema, error) {
+	name := tag.name
+	mode := string(o.defaultMode)
+	if tag.nullable {
+		mode = "nullable"
+	}
 
-		tfs := &bigquery.TableFieldSchema{
-			Mode: mode,
-			Name: name,
-			Type: t,
+	v := pointerGuard(fv)
+	if v.Kind() == reflect.Interface {
+		if o.strict {
+			return nil, fieldError(KindStrict, "interface fields are not allowed in strict mode")
 		}
-		schema.Fields = append(schema.Fields, tfs)
+		concrete, err := o.concreteType(tag, sf)
+		if err != nil {
+			return nil, err
+		}
+		o.report.add(ReportCoerced, false, "%s converted as %s", v.Type(), concrete)
+		v = pointerGuard(concrete)
+	}
 
-		if isSimple {
-			continue
+	tagged, ok, err := taggedField(tag, name, mode)
+	if err != nil {
+		return nil, err
+	}
+	if ok {
+		if isArray(v.Type()) {
+			tagged.Mode = "repeated"
 		}
+		return tagged, nil
+	}
+	if mapped, ok := o.typeMapping(v.Type()); ok {
+		o.report.add(ReportCoerced, false, "%s mapped to %s", v.Type(), strings.ToLower(mapped.Type))
+		return mappedField(mapped, name, mode), nil
+	}
+	if values, ok := enumValues(v.Type(), tag); ok {
+		if err := checkEnum(v.Type()); err != nil {
+			return nil, err
+		}
+		return enumField(name, mode, values), nil
+	}
+	if o.stringType(v.Type()) {
+		o.report.add(ReportCoerced, false, "%s converted to string", v.Type())
+		return &bigquery.TableFieldSchema{Mode: mode, Name: name, Type: "string"}, nil
+	}
 
-		switch kind {
-		case reflect.Struct:
-			mode, tfs.Mode = tfs.Mode, "nullable" // preserve previous value
-			t, fields, err := structConversion(v.Interface())
-			if err != nil {
-				return schema, err
-			}
-			tfs.Type = t
-			if t == "string" {
-				tfs.Mode = mode
+	kind := v.Kind()
+	t, isSimple := simpleType(kind)
+
+	tfs := &bigquery.TableFieldSchema{
+		Mode: mode,
+		Name: name,
+		Type: t,
+	}
+	if isSimple {
+		if err := o.checkScalar(name, v.Type()); err != nil {
+			return nil, err
+		}
+		return tfs, nil
+	}
+
+	switch kind {
+	case reflect.Struct:
+		tfs.Mode = "nullable"
+		t, fields, err := structConversion(v.Interface(), o)
+		if err != nil {
+			return nil, err
+		}
+		tfs.Type = t
+		tfs.Fields = fields
+	case reflect.Array, reflect.Slice:
+		tfs.Mode = "repeated"
+		if elem := v.Type().Elem().Kind(); elem == reflect.Ptr || elem == reflect.Interface {
+			o.report.add(ReportMode, true, "nil elements of %s are dropped; BigQuery arrays can not hold null", v.Type())
+		}
+		subType := pointerGuard(v.Type().Elem()).Type()
+		if subType.Kind() == reflect.Interface {
+			if o.strict {
+				return nil, fieldError(KindStrict, "interface fields are not allowed in strict mode")
 			}
-			tfs.Fields = fields
-		case reflect.Array, reflect.Slice:
-			tfs.Mode = "repeated"
-			subKind := pointerGuard(v.Type().Elem()).Kind()
-			if t, isSimple := simpleType(subKind); isSimple {
-				schema.Fields[i].Type = t
-				continue
+			concrete, err := o.concreteType(tag, sf)
+			if err != nil {
+				return nil, err
 			}
-			if subKind != reflect.Struct {
-				return schema, ErrArrayOfArray
+			o.report.add(ReportCoerced, false, "%s converted as %s", subType, concrete)
+			subType = pointerGuard(concrete).Type()
+		}
+		if mapped, ok := o.typeMapping(subType); ok {
+			o.report.add(ReportCoerced, false, "%s mapped to %s", subType, strings.ToLower(mapped.Type))
+			return mappedField(mapped, name, "repeated"), nil
+		}
+		if values, ok := enumValues(subType, tag); ok {
+			if err := checkEnum(subType); err != nil {
+				return nil, err
 			}
-			subStruct := reflect.Zero(pointerGuard(v.Type().Elem()).Type()).Interface()
-			t, fields, err := structConversion(subStruct)
-			if err != nil {
-				return schema, err
+			return enumField(name, "repeated", values), nil
+		}
+		if o.stringType(subType) {
+			o.report.add(ReportCoerced, false, "%s converted to string", subType)
+			tfs.Type = "string"
+			return tfs, nil
+		}
+		subKind := subType.Kind()
+		if t, isSimple := simpleType(subKind); isSimple {
+			if err := o.checkScalar(name, subType); err != nil {
+				return nil, err
 			}
-			schema.Fields[i].Type = t
-			schema.Fields[i].Fields = fields
-		default:
-			return schema, &ErrInconvertibleType{sf.Type.String()}
+			tfs.Type = t
+			return tfs, nil
+		}
+		if subKind != reflect.Struct {
+			return nil, ErrArrayOfArray
 		}
+		subStruct := reflect.Zero(subType).Interface()
+		t, fields, err := structConversion(subStruct, o)
+		if err != nil {
+			return nil, err
+		}
+		tfs.Type = t
+		tfs.Fields = fields
+	case reflect.Map:
+		entry, ok := mapEntryType(v.Type())
+		if !ok {
+			return nil, &ErrInconvertibleType{sf.Type.String()}
+		}
+		schema, err := toSchema(reflect.Zero(entry).Interface(), o)
+		if err != nil {
+			return nil, err
+		}
+		tfs.Mode = "repeated"
+		tfs.Type = "record"
+		tfs.Fields = schema.Fields
+	default:
+		return nil, &ErrInconvertibleType{sf.Type.String()}
 	}
-	return schema, nil
+	return tfs, nil
 }
 
 // MustToSchema panics if conversion to a schema encounters an error.
-func MustToSchema(src interface{}) *bigquery.TableSchema {
-	schema, err := ToSchema(src)
-	if err != nil {
-		panic(err)
+func MustToSchema(src interface{}, opts ...Option) *bigquery.TableSchema {
+	if len(opts) == 0 {
+		return defaultConverter.MustToSchema(src)
 	}
-	return schema
+	return NewConverter(opts...).MustToSchema(src)
 }
 
 func simpleType(kind reflect.Kind) (string, bool) {
@@ -129,18 +300,21 @@ func simpleType(kind reflect.Kind) (string, bool) {
 	}
 }
 
-func structConversion(src interface{}) (string, []*bigquery.TableFieldSchema, error) {
+func structConversion(src interface{}, o *options) (string, []*bigquery.TableFieldSchema, error) {
 	v := reflect.ValueOf(src)
-	if v.Type().Name() == "Key" && strings.Contains(v.Type().PkgPath(), "appengine") {
-		return "string", nil, nil
-	} else if v.Type().ConvertibleTo(reflect.TypeOf(time.Time{})) {
+	if v.Type().ConvertibleTo(reflect.TypeOf(time.Time{})) {
 		return "timestamp", nil, nil
 	} else {
-		schema, err := ToSchema(src)
+		schema, err := toSchema(src, o)
 		return "record", schema.Fields, err
 	}
 }
 
+// isArray reports whether t converts to a repeated field; byte slices hold a single value.
+func isArray(t reflect.Type) bool {
+	return (t.Kind() == reflect.Slice || t.Kind() == reflect.Array) && t.Elem().Kind() != reflect.Uint8
+}
+
 func pointerGuard(i interface{}) reflect.Value {
 	v, ok := i.(reflect.Value)
 	if !ok {
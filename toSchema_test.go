@@ -63,6 +63,36 @@ var _ = Describe("ToSchema", func() {
 				},
 				`should ignore struct fields when the field's tag is "-" or the field is not exported`,
 			},
+			[]interface{}{
+				struct {
+					unexported string
+					Excluded   string `json:"-"`
+					A          []int
+					B          []struct{ C string }
+				}{},
+				bigquery.TableSchema{
+					Fields: []*bigquery.TableFieldSchema{
+						&bigquery.TableFieldSchema{
+							Mode: "repeated",
+							Name: "A",
+							Type: "integer",
+						},
+						&bigquery.TableFieldSchema{
+							Mode: "repeated",
+							Name: "B",
+							Type: "record",
+							Fields: []*bigquery.TableFieldSchema{
+								&bigquery.TableFieldSchema{
+									Mode: "required",
+									Name: "C",
+									Type: "string",
+								},
+							},
+						},
+					},
+				},
+				"should convert arrays following ignored struct fields",
+			},
 			[]interface{}{
 				struct {
 					A []int
@@ -8,6 +8,9 @@ import (
 	"google.golang.org/api/bigquery/v2"
 )
 
+// ToStructs replaces the slice dst points to with the rows of result, decoding
+// top level columns of simple types only. ResultsToStructs decodes every column
+// type and appends to dst.
 func ToStructs(result *bigquery.QueryResponse, dst interface{}) error {
 	var err error
 	value := reflect.Indirect(reflect.ValueOf(dst))
@@ -0,0 +1,212 @@
+package bqschema
+
+import (
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"math"
+	"reflect"
+	"strconv"
+	"strings"
+	"time"
+
+	"google.golang.org/api/bigquery/v2"
+)
+
+var stringerType = reflect.TypeOf((*fmt.Stringer)(nil)).Elem()
+
+// ErrNilSchema is returned when rows are checked against a nil schema.
+var ErrNilSchema = errors.New("nil schema")
+
+// ValidationError reports a value that does not conform to its schema field.
+type ValidationError struct {
+	Path   string
+	Reason string
+}
+
+func (e *ValidationError) Error() string {
+	return fmt.Sprintf("%s: %s", e.Path, e.Reason)
+}
+
+// Is matches a FieldError target of kind KindValidation, or with no kind,
+// and no path or the path of e.
+func (e *ValidationError) Is(target error) bool {
+	return (&FieldError{Path: e.Path, Kind: KindValidation}).Is(target)
+}
+
+// ValidationErrors lists every violation found in a row.
+type ValidationErrors []*ValidationError
+
+func (e ValidationErrors) Error() string {
+	msgs := make([]string, len(e))
+	for i, err := range e {
+		msgs[i] = err.Error()
+	}
+	return strings.Join(msgs, "; ")
+}
+
+// Unwrap returns the violations, for errors.Is and errors.As.
+func (e ValidationErrors) Unwrap() []error {
+	errs := make([]error, len(e))
+	for i, err := range e {
+		errs[i] = err
+	}
+	return errs
+}
+
+// ValidateValue checks that row conforms to schema: required fields are present,
+// repeated fields are arrays and scalar values are coercible to their column types.
+// Nested records are expected as maps keyed by field name.
+// The returned error, if any, is a ValidationErrors, or ErrNilSchema if schema is nil.
+func ValidateValue(schema *bigquery.TableSchema, row map[string]interface{}) error {
+	if schema == nil {
+		return ErrNilSchema
+	}
+	var errs ValidationErrors
+	validateRecord(schema.Fields, reflect.ValueOf(row), "", &errs)
+	if len(errs) > 0 {
+		return errs
+	}
+	return nil
+}
+
+func validateRecord(fields []*bigquery.TableFieldSchema, row reflect.Value, prefix string, errs *ValidationErrors) {
+	known := make(map[string]bool, len(fields))
+	for _, field := range fields {
+		known[strings.ToLower(field.Name)] = true
+		path := prefix + field.Name
+		v, ok := lookupValue(row, field.Name)
+		if !ok {
+			if strings.EqualFold(field.Mode, "required") {
+				*errs = append(*errs, &ValidationError{path, "required field is missing"})
+			}
+			continue
+		}
+		validateField(field, v, path, errs)
+	}
+
+	for _, key := range row.MapKeys() {
+		if !known[strings.ToLower(key.String())] {
+			*errs = append(*errs, &ValidationError{prefix + key.String(), "no such field in schema"})
+		}
+	}
+}
+
+func validateField(field *bigquery.TableFieldSchema, v reflect.Value, path string, errs *ValidationErrors) {
+	if !strings.EqualFold(field.Mode, "repeated") {
+		validateScalar(field, v, path, errs)
+		return
+	}
+
+	if v.Kind() != reflect.Slice && v.Kind() != reflect.Array {
+		*errs = append(*errs, &ValidationError{path, fmt.Sprintf("repeated field requires an array, got %s", v.Type())})
+		return
+	}
+	for i := 0; i < v.Len(); i++ {
+		elemPath := fmt.Sprintf("%s[%d]", path, i)
+		elem := indirectValue(v.Index(i))
+		if !elem.IsValid() {
+			*errs = append(*errs, &ValidationError{elemPath, "repeated field can not contain null"})
+			continue
+		}
+		validateScalar(field, elem, elemPath, errs)
+	}
+}
+
+func validateScalar(field *bigquery.TableFieldSchema, v reflect.Value, path string, errs *ValidationErrors) {
+	if strings.EqualFold(field.Type, "record") || strings.EqualFold(field.Type, "struct") {
+		if v.Kind() != reflect.Map || v.Type().Key().Kind() != reflect.String {
+			*errs = append(*errs, &ValidationError{path, fmt.Sprintf("record requires a map, got %s", v.Type())})
+			return
+		}
+		validateRecord(field.Fields, v, path+".", errs)
+		return
+	}
+
+	if !coercible(field.Type, v) {
+		*errs = append(*errs, &ValidationError{path, fmt.Sprintf("can not coerce %s to %s", v.Type(), strings.ToLower(field.Type))})
+		return
+	}
+	if canonicalType(field.Type) == "integer" {
+		if err := integerRange(v); err != nil {
+			*errs = append(*errs, &ValidationError{path, err.Error()})
+		}
+	}
+}
+
+// lookupValue finds the non-null value for name in a map, ignoring case like BigQuery does.
+func lookupValue(row reflect.Value, name string) (reflect.Value, bool) {
+	for _, key := range row.MapKeys() {
+		if strings.EqualFold(key.String(), name) {
+			v := indirectValue(row.MapIndex(key))
+			return v, v.IsValid()
+		}
+	}
+	return reflect.Value{}, false
+}
+
+// indirectValue unwraps interfaces and pointers, returning the zero Value for nil.
+func indirectValue(v reflect.Value) reflect.Value {
+	for v.Kind() == reflect.Interface || v.Kind() == reflect.Ptr {
+		if v.IsNil() {
+			return reflect.Value{}
+		}
+		v = v.Elem()
+	}
+	return v
+}
+
+func coercible(bqType string, v reflect.Value) bool {
+	if n, ok := v.Interface().(json.Number); ok {
+		v = reflect.ValueOf(n.String())
+	}
+	kind := v.Kind()
+
+	switch strings.ToLower(bqType) {
+	case "string":
+		return kind == reflect.String || v.Type().Implements(stringerType)
+	case "integer", "int64":
+		switch kind {
+		case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64, reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64:
+			return true
+		case reflect.Float32, reflect.Float64:
+			return v.Float() == math.Trunc(v.Float())
+		case reflect.String:
+			_, err := strconv.ParseInt(v.String(), 10, 64)
+			return err == nil
+		}
+	case "float", "float64", "numeric", "bignumeric":
+		switch kind {
+		case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64, reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64, reflect.Float32, reflect.Float64:
+			return true
+		case reflect.String:
+			_, err := strconv.ParseFloat(v.String(), 64)
+			return err == nil
+		}
+	case "boolean", "bool":
+		switch kind {
+		case reflect.Bool:
+			return true
+		case reflect.String:
+			_, err := strconv.ParseBool(v.String())
+			return err == nil
+		}
+	case "timestamp", "datetime", "date", "time":
+		if v.Type().ConvertibleTo(reflect.TypeOf(time.Time{})) {
+			return true
+		}
+		return kind == reflect.String
+	case "bytes":
+		if kind == reflect.Slice && v.Type().Elem().Kind() == reflect.Uint8 {
+			return true
+		}
+		if kind == reflect.String {
+			_, err := base64.StdEncoding.DecodeString(v.String())
+			return err == nil
+		}
+	default:
+		return true
+	}
+	return false
+}
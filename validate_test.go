@@ -0,0 +1,129 @@
+package bqschema
+
+import (
+	"math"
+	"time"
+
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+
+	"google.golang.org/api/bigquery/v2"
+)
+
+var _ = Describe("ValidateValue", func() {
+	schema := &bigquery.TableSchema{
+		Fields: []*bigquery.TableFieldSchema{
+			&bigquery.TableFieldSchema{Mode: "required", Name: "A", Type: "integer"},
+			&bigquery.TableFieldSchema{Mode: "nullable", Name: "B", Type: "float"},
+			&bigquery.TableFieldSchema{Mode: "repeated", Name: "C", Type: "string"},
+			&bigquery.TableFieldSchema{Mode: "nullable", Name: "D", Type: "timestamp"},
+			&bigquery.TableFieldSchema{
+				Mode: "repeated",
+				Name: "E",
+				Type: "record",
+				Fields: []*bigquery.TableFieldSchema{
+					&bigquery.TableFieldSchema{Mode: "required", Name: "F", Type: "boolean"},
+				},
+			},
+		},
+	}
+
+	Context("when validating rows that match the schema", func() {
+		table := [][]interface{}{
+			[]interface{}{
+				map[string]interface{}{"A": 1},
+				"should accept rows with only required fields",
+			},
+			[]interface{}{
+				map[string]interface{}{
+					"a": "2",
+					"b": 1.5,
+					"c": []string{"x", "y"},
+					"d": time.Now(),
+					"e": []interface{}{map[string]interface{}{"F": true}},
+				},
+				"should accept coercible values regardless of name casing",
+			},
+			[]interface{}{
+				map[string]interface{}{"A": 1.0, "B": nil, "D": "2015-01-02T03:04:05Z"},
+				"should accept integral floats, nulls and timestamp strings",
+			},
+		}
+
+		for _, data := range table {
+			row := data[0].(map[string]interface{})
+			It(data[1].(string), func() {
+				Expect(ValidateValue(schema, row)).To(Succeed())
+			})
+		}
+	})
+
+	Context("when validating rows that do not match the schema", func() {
+		table := [][]interface{}{
+			[]interface{}{
+				map[string]interface{}{"B": 1.0},
+				[]string{"A: required field is missing"},
+				"should report missing required fields",
+			},
+			[]interface{}{
+				map[string]interface{}{"A": 1, "C": "x"},
+				[]string{"C: repeated field requires an array, got string"},
+				"should report repeated fields that are not arrays",
+			},
+			[]interface{}{
+				map[string]interface{}{"A": 1.5, "B": "abc"},
+				[]string{"A: can not coerce float64 to integer", "B: can not coerce string to float"},
+				"should report values that can not be coerced",
+			},
+			[]interface{}{
+				map[string]interface{}{"A": math.Inf(1)},
+				[]string{"A: +Inf overflows integer"},
+				"should report infinite integers as CoerceRow does",
+			},
+			[]interface{}{
+				map[string]interface{}{"A": 1e300},
+				[]string{"A: 1e+300 overflows integer"},
+				"should report floats out of the range of integers",
+			},
+			[]interface{}{
+				map[string]interface{}{"A": uint64(math.MaxUint64)},
+				[]string{"A: 18446744073709551615 overflows integer"},
+				"should report unsigned integers out of the range of integers",
+			},
+			[]interface{}{
+				map[string]interface{}{"A": math.NaN()},
+				[]string{"A: can not coerce float64 to integer"},
+				"should report NaN integers",
+			},
+			[]interface{}{
+				map[string]interface{}{"A": 1, "E": []interface{}{map[string]interface{}{}, nil}},
+				[]string{"E[0].F: required field is missing", "E[1]: repeated field can not contain null"},
+				"should report violations inside repeated records",
+			},
+			[]interface{}{
+				map[string]interface{}{"A": 1, "Z": 1},
+				[]string{"Z: no such field in schema"},
+				"should report fields missing from the schema",
+			},
+		}
+
+		for _, data := range table {
+			row := data[0].(map[string]interface{})
+			expected := data[1].([]string)
+			It(data[2].(string), func() {
+				err := ValidateValue(schema, row)
+				Expect(err).To(BeAssignableToTypeOf(ValidationErrors{}))
+				errs := err.(ValidationErrors)
+				messages := make([]string, len(errs))
+				for i, e := range errs {
+					messages[i] = e.Error()
+				}
+				Expect(messages).To(Equal(expected))
+			})
+		}
+	})
+
+	It("should reject nil schemas", func() {
+		Expect(ValidateValue(nil, map[string]interface{}{"A": 1})).To(Equal(ErrNilSchema))
+	})
+})
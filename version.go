@@ -0,0 +1,109 @@
+package bqschema
+
+import (
+	"fmt"
+	"reflect"
+	"strings"
+
+	"google.golang.org/api/bigquery/v2"
+)
+
+// VersionLabel is the table label StampVersion records schema versions in.
+const VersionLabel = "schema_version"
+
+const versionPrefix = "Schema version: "
+
+// Versioned is implemented by types declaring the version of their schema,
+// bumped by hand as the schema changes.
+type Versioned interface {
+	SchemaVersion() string
+}
+
+// SchemaVersionOf returns the schema version declared by src, or by a pointer
+// to it, and whether it declares one.
+func SchemaVersionOf(src interface{}) (string, bool) {
+	if v, ok := src.(Versioned); ok {
+		return v.SchemaVersion(), true
+	}
+	v := reflect.ValueOf(src)
+	if !v.IsValid() {
+		return "", false
+	}
+	p := reflect.New(v.Type())
+	p.Elem().Set(v)
+	if v, ok := p.Interface().(Versioned); ok {
+		return v.SchemaVersion(), true
+	}
+	return "", false
+}
+
+// StampVersion records the schema version declared by src on table: in the
+// schema_version label, with characters labels do not allow replaced by
+// underscores, and on the last line of the description, replacing the version
+// stamped before. It reports whether src declares a version.
+func StampVersion(table *bigquery.Table, src interface{}) bool {
+	version, ok := SchemaVersionOf(src)
+	if !ok {
+		return false
+	}
+
+	if table.Labels == nil {
+		table.Labels = map[string]string{}
+	}
+	table.Labels[VersionLabel] = labelValue(version)
+
+	description := table.Description
+	if i := strings.LastIndex(description, versionPrefix); i >= 0 && !strings.Contains(description[i:], "\n") {
+		description = strings.TrimRight(description[:i], "\n")
+	}
+	if description != "" {
+		description += "\n\n"
+	}
+	table.Description = description + versionPrefix + version
+	return true
+}
+
+// labelValue lower cases s and replaces the characters label values do not allow.
+func labelValue(s string) string {
+	b := []byte(strings.ToLower(s))
+	for i, c := range b {
+		if (c < 'a' || c > 'z') && (c < '0' || c > '9') && c != '-' && c != '_' {
+			b[i] = '_'
+		}
+	}
+	if len(b) > 63 {
+		b = b[:63]
+	}
+	return string(b)
+}
+
+// Changelog renders the differences between two schemas as a list for release
+// notes, one line per change:
+//
+//   - Added column `email` (STRING, NULLABLE)
+//   - Removed column `legacy`
+//   - Changed type of `score` from INTEGER to FLOAT
+//
+// It returns the empty string if the schemas are equivalent.
+func Changelog(old, new *bigquery.TableSchema) string {
+	d := Diff(old, new)
+	var b strings.Builder
+	for _, c := range d.Added {
+		fmt.Fprintf(&b, "- Added column `%s` (%s, %s)\n", c.Path, strings.ToUpper(canonicalType(c.New.Type)), strings.ToUpper(canonicalMode(c.New.Mode)))
+	}
+	for _, c := range d.Removed {
+		fmt.Fprintf(&b, "- Removed column `%s`\n", c.Path)
+	}
+	for _, c := range d.Changed {
+		if c.TypeChanged() {
+			fmt.Fprintf(&b, "- Changed type of `%s` from %s to %s\n", c.Path, strings.ToUpper(canonicalType(c.Old.Type)), strings.ToUpper(canonicalType(c.New.Type)))
+		}
+		if c.ModeChanged() {
+			fmt.Fprintf(&b, "- Changed mode of `%s` from %s to %s\n", c.Path, strings.ToUpper(canonicalMode(c.Old.Mode)), strings.ToUpper(canonicalMode(c.New.Mode)))
+		}
+		if c.DescriptionChanged() {
+			fmt.Fprintf(&b, "- Changed description of `%s` to %q\n", c.Path, c.New.Description)
+		}
+	}
+	return b.String()
+}
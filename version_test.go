@@ -0,0 +1,81 @@
+package bqschema
+
+import (
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+
+	"google.golang.org/api/bigquery/v2"
+)
+
+type versionedRow struct {
+	ID int64 `json:"id"`
+}
+
+func (versionedRow) SchemaVersion() string { return "2.1.0" }
+
+type versionedPointerRow struct {
+	ID int64 `json:"id"`
+}
+
+func (*versionedPointerRow) SchemaVersion() string { return "V3" }
+
+var _ = Describe("Schema versions", func() {
+	It("should find the version declared by a type", func() {
+		v, ok := SchemaVersionOf(versionedRow{})
+		Expect(ok).To(BeTrue())
+		Expect(v).To(Equal("2.1.0"))
+		v, ok = SchemaVersionOf(versionedPointerRow{})
+		Expect(ok).To(BeTrue())
+		Expect(v).To(Equal("V3"))
+		_, ok = SchemaVersionOf(struct{}{})
+		Expect(ok).To(BeFalse())
+		_, ok = SchemaVersionOf(nil)
+		Expect(ok).To(BeFalse())
+	})
+
+	It("should stamp the version on tables", func() {
+		table := &bigquery.Table{Description: "Users."}
+		Expect(StampVersion(table, versionedRow{})).To(BeTrue())
+		Expect(table.Labels).To(Equal(map[string]string{"schema_version": "2_1_0"}))
+		Expect(table.Description).To(Equal("Users.\n\nSchema version: 2.1.0"))
+
+		Expect(StampVersion(table, versionedPointerRow{})).To(BeTrue())
+		Expect(table.Labels).To(Equal(map[string]string{"schema_version": "v3"}))
+		Expect(table.Description).To(Equal("Users.\n\nSchema version: V3"))
+
+		table = &bigquery.Table{}
+		Expect(StampVersion(table, versionedRow{})).To(BeTrue())
+		Expect(table.Description).To(Equal("Schema version: 2.1.0"))
+		Expect(StampVersion(table, struct{}{})).To(BeFalse())
+	})
+})
+
+var _ = Describe("Changelog", func() {
+	It("should list the changes between schemas", func() {
+		old := &bigquery.TableSchema{Fields: []*bigquery.TableFieldSchema{
+			&bigquery.TableFieldSchema{Mode: "REQUIRED", Name: "id", Type: "INTEGER"},
+			&bigquery.TableFieldSchema{Name: "score", Type: "INT64"},
+			&bigquery.TableFieldSchema{Mode: "NULLABLE", Name: "legacy", Type: "STRING"},
+			&bigquery.TableFieldSchema{Mode: "NULLABLE", Name: "address", Type: "RECORD", Fields: []*bigquery.TableFieldSchema{
+				&bigquery.TableFieldSchema{Mode: "NULLABLE", Name: "city", Type: "STRING"},
+			}},
+		}}
+		new := &bigquery.TableSchema{Fields: []*bigquery.TableFieldSchema{
+			&bigquery.TableFieldSchema{Mode: "required", Name: "id", Type: "integer", Description: "Identifier."},
+			&bigquery.TableFieldSchema{Mode: "required", Name: "score", Type: "float"},
+			&bigquery.TableFieldSchema{Mode: "nullable", Name: "address", Type: "record", Fields: []*bigquery.TableFieldSchema{
+				&bigquery.TableFieldSchema{Mode: "nullable", Name: "city", Type: "string"},
+				&bigquery.TableFieldSchema{Name: "zip", Type: "string"},
+			}},
+			&bigquery.TableFieldSchema{Mode: "repeated", Name: "tags", Type: "string"},
+		}}
+		Expect(Changelog(old, new)).To(Equal("" +
+			"- Added column `address.zip` (STRING, NULLABLE)\n" +
+			"- Added column `tags` (STRING, REPEATED)\n" +
+			"- Removed column `legacy`\n" +
+			"- Changed description of `id` to \"Identifier.\"\n" +
+			"- Changed type of `score` from INTEGER to FLOAT\n" +
+			"- Changed mode of `score` from NULLABLE to REQUIRED\n"))
+		Expect(Changelog(old, old)).To(Equal(""))
+	})
+})
@@ -0,0 +1,131 @@
+package bqschema
+
+import (
+	"fmt"
+	"io"
+	"io/ioutil"
+
+	"google.golang.org/api/bigquery/v2"
+	"gopkg.in/yaml.v2"
+)
+
+// yamlSchema is the YAML representation of a table schema:
+//
+//	fields:
+//	- name: id
+//	  type: integer
+//	  mode: required
+//	- name: address
+//	  type: record
+//	  description: Where the user lives.
+//	  fields:
+//	  - name: city
+//	    type: string
+type yamlSchema struct {
+	Fields []*yamlField `yaml:"fields"`
+}
+
+type yamlField struct {
+	Name                   string       `yaml:"name"`
+	Type                   string       `yaml:"type"`
+	Mode                   string       `yaml:"mode,omitempty"`
+	Description            string       `yaml:"description,omitempty"`
+	MaxLength              int64        `yaml:"maxLength,omitempty"`
+	Precision              int64        `yaml:"precision,omitempty"`
+	Scale                  int64        `yaml:"scale,omitempty"`
+	RoundingMode           string       `yaml:"roundingMode,omitempty"`
+	Collation              string       `yaml:"collation,omitempty"`
+	RangeElementType       string       `yaml:"rangeElementType,omitempty"`
+	DefaultValueExpression string       `yaml:"defaultValueExpression,omitempty"`
+	Fields                 []*yamlField `yaml:"fields,omitempty"`
+}
+
+// ReadYAML reads a schema written by WriteYAML or by hand. Unknown keys are errors.
+func ReadYAML(r io.Reader) (*bigquery.TableSchema, error) {
+	b, err := ioutil.ReadAll(r)
+	if err != nil {
+		return nil, err
+	}
+	var y yamlSchema
+	if err := yaml.UnmarshalStrict(b, &y); err != nil {
+		return nil, err
+	}
+	fields, err := fromYAML(y.Fields, "")
+	if err != nil {
+		return nil, err
+	}
+	return &bigquery.TableSchema{Fields: fields}, nil
+}
+
+// WriteYAML writes schema as YAML, listing the name, type, mode, description
+// and nested fields of each column.
+func WriteYAML(w io.Writer, schema *bigquery.TableSchema) error {
+	b, err := yaml.Marshal(&yamlSchema{Fields: toYAML(schema.Fields)})
+	if err != nil {
+		return err
+	}
+	_, err = w.Write(b)
+	return err
+}
+
+func fromYAML(fields []*yamlField, prefix string) ([]*bigquery.TableFieldSchema, error) {
+	if fields == nil {
+		return nil, nil
+	}
+	tfs := make([]*bigquery.TableFieldSchema, len(fields))
+	for i, f := range fields {
+		if f.Name == "" {
+			return nil, fmt.Errorf("%sfields[%d]: name is required", prefix, i)
+		}
+		if f.Type == "" {
+			return nil, fmt.Errorf("%s%s: type is required", prefix, f.Name)
+		}
+		nested, err := fromYAML(f.Fields, prefix+f.Name+".")
+		if err != nil {
+			return nil, err
+		}
+		tfs[i] = &bigquery.TableFieldSchema{
+			Name:                   f.Name,
+			Type:                   f.Type,
+			Mode:                   f.Mode,
+			Description:            f.Description,
+			MaxLength:              f.MaxLength,
+			Precision:              f.Precision,
+			Scale:                  f.Scale,
+			RoundingMode:           f.RoundingMode,
+			Collation:              f.Collation,
+			DefaultValueExpression: f.DefaultValueExpression,
+			Fields:                 nested,
+		}
+		if f.RangeElementType != "" {
+			tfs[i].RangeElementType = &bigquery.TableFieldSchemaRangeElementType{Type: f.RangeElementType}
+		}
+	}
+	return tfs, nil
+}
+
+func toYAML(fields []*bigquery.TableFieldSchema) []*yamlField {
+	if fields == nil {
+		return nil
+	}
+	y := make([]*yamlField, len(fields))
+	for i, f := range fields {
+		y[i] = &yamlField{
+			Name:                   f.Name,
+			Type:                   f.Type,
+			Mode:                   f.Mode,
+			Description:            f.Description,
+			MaxLength:              f.MaxLength,
+			Precision:              f.Precision,
+			Scale:                  f.Scale,
+			RoundingMode:           f.RoundingMode,
+			Collation:              f.Collation,
+			DefaultValueExpression: f.DefaultValueExpression,
+			Fields:                 toYAML(f.Fields),
+		}
+		if f.RangeElementType != nil {
+			y[i].RangeElementType = f.RangeElementType.Type
+		}
+	}
+	return y
+}
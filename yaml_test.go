@@ -0,0 +1,65 @@
+package bqschema
+
+import (
+	"bytes"
+	"strings"
+
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+
+	"google.golang.org/api/bigquery/v2"
+)
+
+var _ = Describe("YAML", func() {
+	schema := &bigquery.TableSchema{Fields: []*bigquery.TableFieldSchema{
+		&bigquery.TableFieldSchema{Mode: "required", Name: "id", Type: "integer"},
+		&bigquery.TableFieldSchema{Name: "address", Type: "record", Description: "Where the user lives.", Fields: []*bigquery.TableFieldSchema{
+			&bigquery.TableFieldSchema{Mode: "required", Name: "city", Type: "string", Collation: "und:ci"},
+		}},
+		&bigquery.TableFieldSchema{Mode: "nullable", Name: "price", Type: "numeric", Precision: 38, Scale: 9},
+		&bigquery.TableFieldSchema{Name: "stay", Type: "range", RangeElementType: &bigquery.TableFieldSchemaRangeElementType{Type: "date"}},
+	}}
+
+	doc := `fields:
+- name: id
+  type: integer
+  mode: required
+- name: address
+  type: record
+  description: Where the user lives.
+  fields:
+  - name: city
+    type: string
+    mode: required
+    collation: und:ci
+- name: price
+  type: numeric
+  mode: nullable
+  precision: 38
+  scale: 9
+- name: stay
+  type: range
+  rangeElementType: date
+`
+
+	It("should write schemas as YAML", func() {
+		var buf bytes.Buffer
+		Expect(WriteYAML(&buf, schema)).To(Succeed())
+		Expect(buf.String()).To(Equal(doc))
+	})
+
+	It("should read the schemas it writes", func() {
+		read, err := ReadYAML(strings.NewReader(doc))
+		Expect(err).To(BeNil())
+		Expect(read).To(Equal(schema))
+	})
+
+	It("should reject unknown keys and fields without a name or type", func() {
+		_, err := ReadYAML(strings.NewReader("fields:\n- name: id\n  typ: integer\n"))
+		Expect(err).NotTo(BeNil())
+		_, err = ReadYAML(strings.NewReader("fields:\n- name: a\n  type: record\n  fields:\n  - type: string\n"))
+		Expect(err).To(MatchError("a.fields[0]: name is required"))
+		_, err = ReadYAML(strings.NewReader("fields:\n- name: id\n"))
+		Expect(err).To(MatchError("id: type is required"))
+	})
+})